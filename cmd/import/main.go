@@ -0,0 +1,77 @@
+// Command import uploads a CSV or JSONL file of users or bookings to a running server.
+//
+//	go run ./cmd/import -kind users -file users.csv -dry-run
+//	go run ./cmd/import -kind bookings -file bookings.jsonl
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"conference-booking/internal/importer"
+)
+
+func main() {
+	server := flag.String("server", "http://localhost:8080", "base URL of the booking server")
+	kind := flag.String("kind", "", "what to import: users or bookings")
+	file := flag.String("file", "", "CSV or JSONL file to import")
+	dryRun := flag.Bool("dry-run", false, "validate every row without applying anything")
+	flag.Parse()
+
+	if (*kind != "users" && *kind != "bookings") || *file == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	contentType := "text/csv"
+	switch filepath.Ext(*file) {
+	case ".jsonl", ".ndjson":
+		contentType = "application/x-ndjson"
+	}
+
+	endpoint := *server + "/import/" + *kind + "?" + url.Values{"dry_run": {strconv.FormatBool(*dryRun)}}.Encode()
+	resp, err := http.Post(endpoint, contentType, f)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var body struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&body)
+		log.Fatalf("import failed: %s: %s", resp.Status, body.Error)
+	}
+
+	var report importer.Report
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		log.Fatal(err)
+	}
+
+	for _, row := range report.Rows {
+		if row.Status == importer.StatusFailed {
+			fmt.Printf("line %d\t%s\t%s\t%s\n", row.Line, row.Status, row.Key, row.Error)
+		} else {
+			fmt.Printf("line %d\t%s\t%s\t%s\n", row.Line, row.Status, row.Key, row.ID)
+		}
+	}
+	fmt.Printf("total %d, succeeded %d, failed %d in %d batches (dry run: %t)\n", report.Total, report.Succeeded, report.Failed, report.Batches, report.DryRun)
+
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -1,34 +1,179 @@
 package main
 
 import (
+	"context"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
 	"time"
 
 	"conference-booking/internal/booking"
 	"conference-booking/internal/conference"
+	"conference-booking/internal/importer"
+	"conference-booking/internal/notification"
+	"conference-booking/internal/payment"
 	"conference-booking/internal/user"
+	"conference-booking/internal/venue"
+	"conference-booking/pkg/checkin"
+	"conference-booking/pkg/openapi"
+	"conference-booking/pkg/tracing"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 func main() {
+	// Tracing (OTLP or stdout, depending on environment)
+	shutdown, err := tracing.Init(context.Background(), "conference-booking")
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	router := gin.Default()
+	router.Use(otelgin.Middleware("conference-booking"))
+
+	// Request validation against the OpenAPI spec
+	spec, err := openapi.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+	validate, err := openapi.ValidateRequests(spec)
+	if err != nil {
+		log.Fatal(err)
+	}
+	router.Use(validate)
 
 	// In-memory storage
 	conferenceStore := conference.NewInMemoryRepository()
 	userStore := user.NewInMemoryRepository()
 	bookingStore := booking.NewInMemoryRepository(conferenceStore)
+	venueStore := venue.NewInMemoryRepository()
+
+	// Payments go through the local fake provider until a real one is configured
+	payments := payment.NewFakeProvider()
+
+	// Notifications are logged until a delivery channel is configured
+	notifier := notification.NewLogNotifier()
+
+	// Check-in tokens are signed with CHECKIN_SECRET (random per process when unset)
+	signer, err := checkin.NewSigner(os.Getenv("CHECKIN_SECRET"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Booking settings: the defaults, overridden per deployment. No-show thresholds of 0 (the
+	// default) disable deprioritizing or blocking
+	bookingConfig := booking.DefaultConfig
+	bookingConfig.NoShows = booking.NoShowRules{
+		DeprioritizeAfter: envInt("NO_SHOW_DEPRIORITIZE_AFTER"),
+		BlockAfter:        envInt("NO_SHOW_BLOCK_AFTER"),
+	}
+	bookingConfig.PaymentHold = envDuration("PAYMENT_HOLD", bookingConfig.PaymentHold)
+	bookingConfig.CheckInOpensBefore = envDuration("CHECKIN_OPENS_BEFORE", bookingConfig.CheckInOpensBefore)
+	bookingConfig.HoldTTL = envDuration("HOLD_TTL", bookingConfig.HoldTTL)
+
+	// Conference validation rules: the defaults, overridden per deployment
+	rules := conference.DefaultRuleConfig
+	rules.MaxDuration = envDuration("CONFERENCE_MAX_DURATION", rules.MaxDuration)
+	rules.MinLeadTime = envDuration("CONFERENCE_MIN_LEAD_TIME", rules.MinLeadTime)
+	rules.NoPastStart = os.Getenv("CONFERENCE_ALLOW_PAST_START") != "true"
+	rules.MinCapacity = envIntOr("CONFERENCE_MIN_CAPACITY", rules.MinCapacity)
+	rules.MaxCapacity = envIntOr("CONFERENCE_MAX_CAPACITY", rules.MaxCapacity)
+	rules.NameMaxLength = envIntOr("CONFERENCE_NAME_MAX_LENGTH", rules.NameMaxLength)
+	if pattern, ok := os.LookupEnv("CONFERENCE_NAME_PATTERN"); ok {
+		rules.NamePattern = pattern
+	}
+	conferenceConfig := conference.DefaultConfig
+	conferenceConfig.Rules, err = conference.NewRuleSet(rules)
+	if err != nil {
+		log.Fatal(err)
+	}
+	conferenceConfig.SeriesHorizon = envDuration("SERIES_HORIZON", conferenceConfig.SeriesHorizon)
 
 	// Initialize services
-	bookingService := booking.NewService(conferenceStore, userStore, bookingStore)
+	bookingService := booking.NewService(conferenceStore, userStore, bookingStore, payments, notifier, signer, bookingConfig)
+
+	conferenceService := conference.NewService(conferenceStore, venueStore, conferenceConfig)
 
 	// Start cleanup goroutine (e.g., every 15 minutes)
 	bookingService.StartBookingCleanup(15 * time.Minute)
 
+	// Generate the occurrences of conference series as their horizon moves forward
+	conferenceService.StartSeriesGeneration(24 * time.Hour)
+
 	// Register routes
-	conference.RegisterRoutes(router, conferenceStore)
+	openapi.RegisterRoutes(router)
+	conference.RegisterRoutes(router, conferenceStore, venueStore, conferenceConfig)
+	venue.RegisterRoutes(router, venueStore)
 	user.RegisterRoutes(router, userStore)
-	booking.RegisterRoutes(router, conferenceStore, userStore, bookingStore)
+	booking.RegisterRoutes(router, conferenceStore, userStore, bookingStore, payments, notifier, signer, bookingConfig)
+	importer.RegisterRoutes(router, conferenceStore, userStore, bookingStore, payments, notifier, signer, bookingConfig)
+
+	// Serve until the server fails or SIGINT/SIGTERM arrives, then drain requests and flush the
+	// spans still batched before exiting
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	server := &http.Server{Addr: ":8080", Handler: router}
+	served := make(chan error, 1)
+	go func() {
+		served <- server.ListenAndServe()
+	}()
+
+	exitCode := 0
+	select {
+	case err := <-served:
+		log.Print(err)
+		exitCode = 1
+	case <-ctx.Done():
+		log.Print("shutting down")
+	}
+
+	stop()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Print(err)
+		exitCode = 1
+	}
+	if err := shutdown(shutdownCtx); err != nil {
+		log.Print(err)
+		exitCode = 1
+	}
+	cancel()
+	os.Exit(exitCode)
+}
+
+// envInt reads a non-negative integer from the environment; unset means 0.
+func envInt(name string) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		log.Fatalf("%s must be a non-negative integer", name)
+	}
+	return n
+}
+
+// envIntOr is envInt with a fallback for when the variable is unset.
+func envIntOr(name string, fallback int) int {
+	if os.Getenv(name) == "" {
+		return fallback
+	}
+	return envInt(name)
+}
 
-	log.Fatal(router.Run(":8080"))
+// envDuration reads a non-negative duration such as "12h" from the environment; 0 disables the rule.
+func envDuration(name string, fallback time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		log.Fatalf("%s must be a non-negative duration such as 12h", name)
+	}
+	return d
 }
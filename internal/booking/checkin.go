@@ -0,0 +1,118 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"conference-booking/pkg/checkin"
+	apperrors "conference-booking/pkg/errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	ErrCheckInClosed    = errors.New("check-in is not open")
+	ErrAlreadyCheckedIn = errors.New("booking already checked in")
+)
+
+// GetCheckInToken returns the signed check-in token of a confirmed booking. Only its holder may fetch it.
+func (s *service) GetCheckInToken(ctx context.Context, bookingID, requesterID string) (string, error) {
+	ctx, span := tracer.Start(ctx, "booking.Service.GetCheckInToken", trace.WithAttributes(attribute.String("booking.id", bookingID)))
+	defer span.End()
+
+	booking, err := s.bookingRepo.FindByID(ctx, bookingID)
+	if err != nil {
+		return "", err
+	}
+	if booking.UserID == "" || booking.UserID != requesterID {
+		return "", apperrors.ErrForbidden
+	}
+	if booking.Status != "Confirmed" && booking.Status != "Attended" {
+		return "", ErrInvalidAction
+	}
+	return s.signer.Sign(booking.ID, booking.UserID), nil
+}
+
+// CheckIn validates a scanned token and marks its booking Attended. Only the conference owner may
+// scan tokens, and only while check-in is open. Tokens of transferred bookings no longer verify, and a
+// second scan of the same token fails with ErrAlreadyCheckedIn.
+func (s *service) CheckIn(ctx context.Context, req CheckInRequest, requesterID string) (*Booking, error) {
+	ctx, span := tracer.Start(ctx, "booking.Service.CheckIn")
+	defer span.End()
+
+	bookingID, userID, err := s.signer.Verify(req.Token)
+	if err != nil {
+		return nil, err
+	}
+	booking, err := s.bookingRepo.FindByID(ctx, bookingID)
+	if err != nil || booking.UserID != userID {
+		return nil, checkin.ErrInvalidToken
+	}
+	span.SetAttributes(attribute.String("booking.id", booking.ID))
+
+	p, err := s.findPool(ctx, booking)
+	if err != nil {
+		return nil, err
+	}
+	if p.conf.OwnerID == "" || p.conf.OwnerID != requesterID {
+		return nil, apperrors.ErrForbidden
+	}
+
+	if booking.Status == "Attended" {
+		return nil, ErrAlreadyCheckedIn
+	}
+	if booking.Status != "Confirmed" {
+		return nil, ErrInvalidAction
+	}
+	now := time.Now()
+	if now.Before(p.start().Add(-s.cfg.CheckInOpensBefore)) || !now.Before(p.end()) {
+		return nil, ErrCheckInClosed
+	}
+
+	if err := s.bookingRepo.CheckIn(ctx, booking.ID, now.UTC()); err != nil {
+		if errors.Is(err, apperrors.ErrConflict) {
+			return nil, ErrAlreadyCheckedIn
+		}
+		return nil, err
+	}
+	return booking, nil
+}
+
+// GetAttendance counts booked and checked-in seats of a conference and its sessions.
+// Only the owner of the conference may see it.
+func (s *service) GetAttendance(ctx context.Context, conferenceName, requesterID string) (*Attendance, error) {
+	ctx, span := tracer.Start(ctx, "booking.Service.GetAttendance", trace.WithAttributes(attribute.String("conference.id", conferenceName)))
+	defer span.End()
+
+	conf, err := s.confRepo.FindByName(ctx, conferenceName)
+	if err != nil {
+		return nil, err
+	}
+	if conf.OwnerID == "" || conf.OwnerID != requesterID {
+		return nil, apperrors.ErrForbidden
+	}
+
+	attendance := &Attendance{Conference: conf.Name, Sessions: []*SessionAttendance{}}
+	sessions := map[string]*SessionAttendance{}
+	for _, session := range s.confRepo.FindSessions(ctx, conf.Name) {
+		sessions[session.ID] = &SessionAttendance{SessionID: session.ID}
+		attendance.Sessions = append(attendance.Sessions, sessions[session.ID])
+	}
+
+	for _, booking := range s.bookingRepo.FindByConference(ctx, conf.Name) {
+		if booking.Status != "Confirmed" && booking.Status != "Attended" {
+			continue
+		}
+		booked, attended := &attendance.Booked, &attendance.Attended
+		if session, ok := sessions[booking.SessionID]; ok {
+			booked, attended = &session.Booked, &session.Attended
+		}
+		*booked++
+		if booking.Status == "Attended" {
+			*attended++
+		}
+	}
+	return attendance, nil
+}
@@ -1,32 +1,75 @@
 package booking
 
 import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"mime"
 	"net/http"
+	"time"
 
 	"conference-booking/internal/conference"
+	"conference-booking/internal/notification"
+	"conference-booking/internal/payment"
 	"conference-booking/internal/user"
+	"conference-booking/pkg/auth"
+	"conference-booking/pkg/checkin"
+	apperrors "conference-booking/pkg/errors"
+	"conference-booking/pkg/ical"
+	"conference-booking/pkg/query"
 
 	"github.com/gin-gonic/gin"
 )
 
-func RegisterRoutes(router *gin.Engine, confRepo conference.Repository, userRepo user.Repository, bookingRepo Repository) {
-	h := NewHandler(confRepo, userRepo, bookingRepo)
+func RegisterRoutes(router *gin.Engine, confRepo conference.Repository, userRepo user.Repository, bookingRepo Repository, payments payment.Provider, notifier notification.Notifier, signer *checkin.Signer, cfg Config) {
+	h := NewHandler(confRepo, userRepo, bookingRepo, payments, notifier, signer, cfg)
 	group := router.Group("/booking")
 	{
 		group.POST("", h.BookConference)
+		group.GET("", h.ListBookings)
 		group.POST("/waitlist/confirm", h.ConfirmWaitlistBooking)
 		group.DELETE("/:id", h.CancelBooking)
 		group.GET("/:id", h.GetBookingStatus)
+		group.GET("/:id/position", h.GetWaitlistPosition)
+		group.POST("/:id/transfer", h.TransferBooking)
+		group.GET("/:id/checkin-token", h.GetCheckInToken)
+		group.GET("/:id/checkin.png", h.GetCheckInQRCode)
+		group.POST("/hold", h.HoldSeat)
+		group.POST("/hold/:id/commit", h.CommitHold)
+		group.POST("/series", h.BookSeries)
+		group.GET("/series/:id", h.GetSeriesBooking)
+		group.DELETE("/series/:id", h.CancelSeriesBooking)
+		group.POST("/group", h.BookGroup)
+		group.GET("/group/:id", h.GetGroup)
+		group.POST("/group/:id/assign", h.AssignSeat)
+		group.DELETE("/group/:id", h.CancelGroup)
 	}
+
+	orders := router.Group("/order")
+	{
+		orders.GET("/:id", h.GetOrder)
+		orders.POST("/:id/pay", h.PayOrder)
+	}
+
+	// Organiser views live under the conference path but need booking data
+	router.GET("/conference/:name/bookings", h.GetRoster)
+	router.GET("/conference/:name/bookings/export", h.ExportRoster)
+	router.GET("/conference/:name/attendance", h.GetAttendance)
+	router.POST("/conference/:name/draw", h.DrawLottery)
+	router.GET("/conference/:name/draw", h.GetDraw)
+	router.GET("/conference/:name/seats", h.GetSeatAvailability)
+	router.POST("/conference/:name/cancel", h.CancelConference)
+	router.POST("/checkin", h.CheckIn)
+	router.GET("/user/:id/calendar.ics", h.GetUserCalendar)
 }
 
 type Handler struct {
 	service Service
 }
 
-func NewHandler(confRepo conference.Repository, userRepo user.Repository, bookingRepo Repository) *Handler {
+func NewHandler(confRepo conference.Repository, userRepo user.Repository, bookingRepo Repository, payments payment.Provider, notifier notification.Notifier, signer *checkin.Signer, cfg Config) *Handler {
 	return &Handler{
-		service: NewService(confRepo, userRepo, bookingRepo),
+		service: NewService(confRepo, userRepo, bookingRepo, payments, notifier, signer, cfg),
 	}
 }
 
@@ -37,9 +80,9 @@ func (h *Handler) BookConference(c *gin.Context) {
 		return
 	}
 
-	bookingID, err := h.service.BookConference(req)
+	bookingID, err := h.service.BookConference(c.Request.Context(), req)
 	if err != nil {
-		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		c.JSON(http.StatusConflict, conflictBody(err))
 		return
 	}
 
@@ -53,8 +96,8 @@ func (h *Handler) ConfirmWaitlistBooking(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.ConfirmWaitlistBooking(req.BookingID); err != nil {
-		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	if err := h.service.ConfirmWaitlistBooking(c.Request.Context(), req); err != nil {
+		c.JSON(http.StatusConflict, conflictBody(err))
 		return
 	}
 
@@ -64,8 +107,8 @@ func (h *Handler) ConfirmWaitlistBooking(c *gin.Context) {
 func (h *Handler) CancelBooking(c *gin.Context) {
 	bookingID := c.Param("id")
 
-	if err := h.service.CancelBooking(bookingID); err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	if err := h.service.CancelBooking(c.Request.Context(), bookingID); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -75,7 +118,7 @@ func (h *Handler) CancelBooking(c *gin.Context) {
 func (h *Handler) GetBookingStatus(c *gin.Context) {
 	bookingID := c.Param("id")
 
-	status, err := h.service.GetBookingStatus(bookingID)
+	status, err := h.service.GetBookingStatus(c.Request.Context(), bookingID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
@@ -83,3 +126,409 @@ func (h *Handler) GetBookingStatus(c *gin.Context) {
 
 	c.JSON(http.StatusOK, status)
 }
+
+// HoldSeat takes a seat off the market for a short time; see CommitHold.
+func (h *Handler) HoldSeat(c *gin.Context) {
+	var req HoldSeatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hold, err := h.service.HoldSeat(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, hold)
+}
+
+// CommitHold turns the caller's seat hold into a booking. The body may be omitted.
+func (h *Handler) CommitHold(c *gin.Context) {
+	var req CommitHoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	booking, err := h.service.CommitHold(c.Request.Context(), c.Param("id"), req, auth.UserID(c))
+	if err != nil {
+		c.JSON(errorStatus(err), conflictBody(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, booking)
+}
+
+// GetWaitlistPosition reports the place of a waitlisted booking in its promotion order.
+func (h *Handler) GetWaitlistPosition(c *gin.Context) {
+	position, err := h.service.GetWaitlistPosition(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, position)
+}
+
+func (h *Handler) ListBookings(c *gin.Context) {
+	q, err := query.FromRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := h.service.ListBookings(c.Request.Context(), q, auth.UserID(c))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *Handler) GetRoster(c *gin.Context) {
+	roster, err := h.service.GetRoster(c.Request.Context(), c.Param("name"), auth.UserID(c))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, roster)
+}
+
+// ExportRoster downloads the attendee roster (list=roster, everyone holding a seat) or the
+// waitlist (list=waitlist) as JSON or CSV (format=json|csv).
+func (h *Handler) ExportRoster(c *gin.Context) {
+	roster, err := h.service.GetRoster(c.Request.Context(), c.Param("name"), auth.UserID(c))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	list := c.DefaultQuery("list", "roster")
+	var attendees []*Attendee
+	switch list {
+	case "roster":
+		attendees = roster.SeatHolders()
+	case "waitlist":
+		attendees = roster.Waitlisted
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": apperrors.ErrInvalidInput.Error()})
+		return
+	}
+
+	// Conference names are free text, so the filename is quoted or encoded as the header needs
+	filename := roster.Conference + "-" + list
+	switch c.DefaultQuery("format", "json") {
+	case "json":
+		c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename + ".json"}))
+		c.JSON(http.StatusOK, attendees)
+	case "csv":
+		c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename + ".csv"}))
+		c.Header("Content-Type", "text/csv")
+		c.Status(http.StatusOK)
+
+		w := csv.NewWriter(c.Writer)
+		w.Write([]string{"booking_id", "user_id", "name", "email", "status", "session_id", "ticket_type", "waitlist_until", "created_at"})
+		for _, a := range attendees {
+			waitlistUntil := ""
+			if a.WaitlistUntil != nil {
+				waitlistUntil = a.WaitlistUntil.Format(time.RFC3339)
+			}
+			w.Write([]string{a.BookingID, a.User.ID, a.User.Name, a.User.Email, a.Status, a.SessionID, a.TicketType, waitlistUntil, a.CreatedAt.Format(time.RFC3339)})
+		}
+		w.Flush()
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": apperrors.ErrInvalidInput.Error()})
+	}
+}
+
+// conflictBody adds the conflicting booking to the error response of an overlap.
+func conflictBody(err error) gin.H {
+	body := gin.H{"error": err.Error()}
+	var overlap *OverlapError
+	if errors.As(err, &overlap) {
+		body["conflicting_booking"] = overlap.Conflicting
+	}
+	return body
+}
+
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, apperrors.ErrInvalidInput), errors.Is(err, checkin.ErrInvalidToken):
+		return http.StatusBadRequest
+	case errors.Is(err, apperrors.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, apperrors.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, payment.ErrDeclined):
+		return http.StatusPaymentRequired
+	default:
+		return http.StatusConflict
+	}
+}
+
+// GetUserCalendar serves the user's confirmed conferences as an iCalendar feed.
+// The feed is built on every request, so changed or cancelled bookings show up on the next refresh.
+func (h *Handler) GetUserCalendar(c *gin.Context) {
+	entries, err := h.service.GetUserCalendar(c.Request.Context(), c.Param("id"), c.Query("token"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	events := make([]ical.Event, 0, len(entries))
+	for _, entry := range entries {
+		event := ical.Event{
+			UID:         entry.BookingID + "@conference-booking",
+			Summary:     entry.Conference.Name,
+			Description: "Booking " + entry.BookingID,
+			Start:       entry.Conference.StartTime,
+			End:         entry.Conference.EndTime,
+		}
+		if entry.Session != nil {
+			event.Summary = entry.Conference.Name + ": " + entry.Session.Title
+			event.Start = entry.Session.StartTime
+			event.End = entry.Session.EndTime
+		}
+		events = append(events, event)
+	}
+
+	c.Header("Content-Type", ical.ContentType)
+	c.Status(http.StatusOK)
+	ical.Write(c.Writer, "Conference bookings", events)
+}
+
+func (h *Handler) BookGroup(c *gin.Context) {
+	var req BookGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	group, err := h.service.BookGroup(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, group)
+}
+
+func (h *Handler) BookSeries(c *gin.Context) {
+	var req BookSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	seriesBooking, err := h.service.BookSeries(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(errorStatus(err), conflictBody(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, seriesBooking)
+}
+
+func (h *Handler) GetSeriesBooking(c *gin.Context) {
+	seriesBooking, err := h.service.GetSeriesBooking(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, seriesBooking)
+}
+
+// CancelSeriesBooking stops a series booking and cancels its future occurrences.
+func (h *Handler) CancelSeriesBooking(c *gin.Context) {
+	seriesBooking, err := h.service.CancelSeriesBooking(c.Request.Context(), c.Param("id"), auth.UserID(c))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, seriesBooking)
+}
+
+// CancelConference cancels a conference together with its bookings and waitlists.
+func (h *Handler) CancelConference(c *gin.Context) {
+	conf, err := h.service.CancelConference(c.Request.Context(), c.Param("name"), auth.UserID(c))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, conf)
+}
+
+func (h *Handler) GetGroup(c *gin.Context) {
+	group, err := h.service.GetGroup(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, group)
+}
+
+func (h *Handler) AssignSeat(c *gin.Context) {
+	var req AssignSeatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	seat, err := h.service.AssignSeat(c.Request.Context(), c.Param("id"), req, auth.UserID(c))
+	if err != nil {
+		c.JSON(errorStatus(err), conflictBody(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, seat)
+}
+
+func (h *Handler) CancelGroup(c *gin.Context) {
+	if err := h.service.CancelGroup(c.Request.Context(), c.Param("id"), auth.UserID(c)); err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+func (h *Handler) GetOrder(c *gin.Context) {
+	order, err := h.service.GetOrder(c.Request.Context(), c.Param("id"), auth.UserID(c))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+func (h *Handler) PayOrder(c *gin.Context) {
+	var req PayOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	order, err := h.service.PayOrder(c.Request.Context(), c.Param("id"), req, auth.UserID(c))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+func (h *Handler) TransferBooking(c *gin.Context) {
+	var req TransferBookingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	booking, err := h.service.TransferBooking(c.Request.Context(), c.Param("id"), req, auth.UserID(c))
+	if err != nil {
+		c.JSON(errorStatus(err), conflictBody(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, booking)
+}
+
+func (h *Handler) GetCheckInToken(c *gin.Context) {
+	token, err := h.service.GetCheckInToken(c.Request.Context(), c.Param("id"), auth.UserID(c))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"token": token})
+}
+
+// GetCheckInQRCode renders the check-in token of a booking as a QR code to show at the entrance.
+func (h *Handler) GetCheckInQRCode(c *gin.Context) {
+	token, err := h.service.GetCheckInToken(c.Request.Context(), c.Param("id"), auth.UserID(c))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	png, err := checkin.QRCode(token, 256)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Data(http.StatusOK, checkin.PNGContentType, png)
+}
+
+func (h *Handler) CheckIn(c *gin.Context) {
+	var req CheckInRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	booking, err := h.service.CheckIn(c.Request.Context(), req, auth.UserID(c))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, booking)
+}
+
+// DrawLottery runs a conference's lottery now. The body, with an optional seed, may be omitted.
+func (h *Handler) DrawLottery(c *gin.Context) {
+	var req DrawRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	draw, err := h.service.DrawLottery(c.Request.Context(), c.Param("name"), req, auth.UserID(c))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, draw)
+}
+
+func (h *Handler) GetDraw(c *gin.Context) {
+	draw, err := h.service.GetDraw(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, draw)
+}
+
+// GetSeatAvailability lists the seats of a conference's seat map and which of them are free.
+func (h *Handler) GetSeatAvailability(c *gin.Context) {
+	availability, err := h.service.GetSeatAvailability(c.Request.Context(), c.Param("name"), auth.UserID(c))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, availability)
+}
+
+func (h *Handler) GetAttendance(c *gin.Context) {
+	attendance, err := h.service.GetAttendance(c.Request.Context(), c.Param("name"), auth.UserID(c))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, attendance)
+}
@@ -0,0 +1,187 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	apperrors "conference-booking/pkg/errors"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var ErrHoldExpired = errors.New("seat hold expired")
+
+// HoldSeat takes a seat of a conference, session or ticket type out of the pool for Config.HoldTTL.
+// Holds never waitlist: without a free seat the request fails. Expired holds are released by the next
+// request that needs the seats of their conference, or else by the cleanup worker.
+func (s *service) HoldSeat(ctx context.Context, req HoldSeatRequest) (*Hold, error) {
+	ctx, span := tracer.Start(ctx, "booking.Service.HoldSeat", trace.WithAttributes(attribute.String("conference.id", req.ConferenceName), attribute.String("user.id", req.UserID)))
+	defer span.End()
+
+	p, err := s.resolvePool(ctx, req.ConferenceName, req.SessionID, req.TicketType, req.Code)
+	if err != nil {
+		return nil, err
+	}
+	if p.conf.Lottery.Pending() && req.SessionID == "" {
+		return nil, ErrLotteryPending
+	}
+
+	u, err := s.userRepo.FindByID(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkNoShows(u); err != nil {
+		return nil, err
+	}
+	if existing, err := s.bookingRepo.FindActiveBooking(ctx, req.UserID, p.conf.Name, req.SessionID); err == nil {
+		return nil, errors.New("user already has an active booking with ID: " + existing.ID)
+	}
+	if existing, err := s.bookingRepo.FindActiveHold(ctx, req.UserID, p.conf.Name, req.SessionID); err == nil {
+		return nil, errors.New("user already holds a seat with hold ID: " + existing.ID)
+	}
+
+	if p.available() <= 0 {
+		return nil, ErrSlotUnavailable
+	}
+	seat, err := s.seats(ctx, p).pick(req.Seat, req.Accessible)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.adjustSlots(ctx, p, -1); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	hold := &Hold{
+		ID:             uuid.New().String(),
+		UserID:         req.UserID,
+		ConferenceName: p.conf.Name,
+		SessionID:      req.SessionID,
+		TicketType:     req.TicketType,
+		Code:           req.Code,
+		Seat:           seat,
+		Accessible:     req.Accessible,
+		Status:         HoldActive,
+		ExpiresAt:      now.Add(s.cfg.HoldTTL),
+		CreatedAt:      now,
+	}
+	if err := s.bookingRepo.CreateHold(ctx, hold); err != nil {
+		return nil, err
+	}
+	return hold, nil
+}
+
+// CommitHold turns an active hold into a booking on its seat: confirmed, or PendingPayment for
+// priced tickets. Only the user holding the seat may commit it.
+func (s *service) CommitHold(ctx context.Context, holdID string, req CommitHoldRequest, requesterID string) (*Booking, error) {
+	ctx, span := tracer.Start(ctx, "booking.Service.CommitHold", trace.WithAttributes(attribute.String("hold.id", holdID)))
+	defer span.End()
+
+	hold, err := s.bookingRepo.FindHold(ctx, holdID)
+	if err != nil {
+		return nil, err
+	}
+	if hold.UserID != requesterID {
+		return nil, apperrors.ErrForbidden
+	}
+	if hold.Status != HoldActive {
+		return nil, ErrInvalidAction
+	}
+	if !hold.ExpiresAt.After(time.Now()) {
+		if err := s.releaseHold(ctx, hold); err != nil {
+			return nil, err
+		}
+		return nil, ErrHoldExpired
+	}
+
+	// Claim the hold so that it is neither committed twice nor released while being committed
+	if err := s.bookingRepo.UpdateHoldStatus(ctx, hold.ID, HoldActive, HoldCommitted); err != nil {
+		if errors.Is(err, apperrors.ErrConflict) {
+			return nil, ErrInvalidAction
+		}
+		return nil, err
+	}
+	booking, err := s.commitHold(ctx, hold, req)
+	if err != nil {
+		// Give the hold back; if it has expired meanwhile, it is released as usual
+		_ = s.bookingRepo.UpdateHoldStatus(ctx, hold.ID, HoldCommitted, HoldActive)
+		return nil, err
+	}
+	return booking, nil
+}
+
+// commitHold books the seat of a claimed hold.
+func (s *service) commitHold(ctx context.Context, hold *Hold, req CommitHoldRequest) (*Booking, error) {
+	p, err := s.resolvePool(ctx, hold.ConferenceName, hold.SessionID, hold.TicketType, hold.Code)
+	if err != nil {
+		return nil, err
+	}
+	if existing, err := s.bookingRepo.FindActiveBooking(ctx, hold.UserID, hold.ConferenceName, hold.SessionID); err == nil {
+		return nil, errors.New("user already has an active booking with ID: " + existing.ID)
+	}
+	if !req.AllowOverlap {
+		if err := s.checkOverlap(ctx, hold.UserID, p); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.redeemCode(ctx, p, 1); err != nil {
+		return nil, err
+	}
+
+	// The seat was taken from the pool when the hold was placed
+	booking := &Booking{
+		ID:           uuid.New().String(),
+		UserID:       hold.UserID,
+		ConferenceID: hold.ConferenceName,
+		SessionID:    hold.SessionID,
+		TicketType:   hold.TicketType,
+		Code:         hold.Code,
+		Seat:         hold.Seat,
+		Accessible:   hold.Accessible,
+		Status:       "Confirmed",
+		CreatedAt:    time.Now().UTC(),
+	}
+	if err := s.holdForPayment(ctx, p, hold.UserID, booking); err != nil {
+		return nil, err
+	}
+	if err := s.bookingRepo.Create(ctx, booking); err != nil {
+		return nil, err
+	}
+
+	hold.BookingID = booking.ID
+	if err := s.bookingRepo.UpdateHold(ctx, hold); err != nil {
+		return nil, err
+	}
+	return booking, nil
+}
+
+// releaseExpiredHolds releases the holds of a conference whose time has run out, so that their
+// seats count as free without waiting for the cleanup worker.
+func (s *service) releaseExpiredHolds(ctx context.Context, conferenceName string, now time.Time) {
+	for _, hold := range s.bookingRepo.FindExpiredHolds(ctx, now) {
+		if hold.ConferenceName == conferenceName {
+			_ = s.releaseHold(ctx, hold)
+		}
+	}
+}
+
+// releaseHold expires an active hold and gives its seat back to the pool, offering it to the
+// waitlist. Holds committed or released meanwhile are left alone.
+func (s *service) releaseHold(ctx context.Context, hold *Hold) error {
+	if err := s.bookingRepo.UpdateHoldStatus(ctx, hold.ID, HoldActive, HoldExpired); err != nil {
+		if errors.Is(err, apperrors.ErrConflict) {
+			return nil
+		}
+		return err
+	}
+
+	// Holds always draw from the general pool, never from a code's reserve
+	p, err := s.findPool(ctx, &Booking{ConferenceID: hold.ConferenceName, SessionID: hold.SessionID, TicketType: hold.TicketType})
+	if err != nil {
+		return err
+	}
+	return s.releaseSeat(ctx, p, hold.Seat)
+}
@@ -0,0 +1,108 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"conference-booking/internal/conference"
+	apperrors "conference-booking/pkg/errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var ErrRegistrationClosed = errors.New("registration is not open")
+
+// checkRegistration allows new bookings, holds and lottery entries only while the conference is
+// published and has not started. Drafts are invisible, so they are not found.
+func checkRegistration(conf *conference.Conference, now time.Time) error {
+	switch state := conf.State(now); state {
+	case conference.StatusPublished:
+		return nil
+	case conference.StatusDraft:
+		return apperrors.ErrNotFound
+	default:
+		return fmt.Errorf("%w: conference is %s", ErrRegistrationClosed, state)
+	}
+}
+
+// CancelConference cancels a conference with all its bookings, waitlist and lottery entries and
+// holds. Paid seats are refunded in full whatever the cancellation policy, and every affected user
+// is notified once. Only the owner may cancel, and not after the conference has ended.
+// A booking that fails to cancel (e.g. its refund is declined) does not stop the others; it is
+// reported in the error and left as it is, and cancelling the conference again retries it.
+func (s *service) CancelConference(ctx context.Context, conferenceName, requesterID string) (*conference.Conference, error) {
+	ctx, span := tracer.Start(ctx, "booking.Service.CancelConference", trace.WithAttributes(attribute.String("conference.id", conferenceName)))
+	defer span.End()
+
+	conf, err := s.confRepo.FindByName(ctx, conferenceName)
+	if err != nil {
+		return nil, err
+	}
+	if conf.OwnerID == "" || conf.OwnerID != requesterID {
+		return nil, apperrors.ErrForbidden
+	}
+	now := time.Now()
+	if state := conf.State(now); state == conference.StatusCompleted {
+		return nil, fmt.Errorf("%w: conference is %s", ErrInvalidAction, state)
+	}
+
+	// Cancel the conference first so that nothing new is booked meanwhile
+	if conf.Status != conference.StatusCancelled {
+		conf.Status = conference.StatusCancelled
+		if err := s.confRepo.Update(ctx, conf); err != nil {
+			return nil, err
+		}
+	}
+
+	var failed []error
+	notified := make(map[string]bool)
+	for _, booking := range s.bookingRepo.FindByConference(ctx, conf.Name) {
+		switch booking.Status {
+		case "Canceled", "Cancelled", "Expired", "NoShow", "Attended":
+			continue
+		}
+		if err := s.cancelForConference(ctx, booking, now); err != nil {
+			failed = append(failed, fmt.Errorf("booking %s: %w", booking.ID, err))
+			continue
+		}
+
+		for _, userID := range []string{booking.UserID, booking.BookerID} {
+			if userID != "" && !notified[userID] {
+				notified[userID] = true
+				s.notify(ctx, userID, "Conference cancelled", conf.Name+" has been cancelled, and your booking "+booking.ID+" with it.")
+			}
+		}
+	}
+
+	for _, hold := range s.bookingRepo.FindActiveHolds(ctx, conf.Name) {
+		err := s.bookingRepo.UpdateHoldStatus(ctx, hold.ID, HoldActive, HoldExpired)
+		if err != nil && !errors.Is(err, apperrors.ErrConflict) {
+			failed = append(failed, fmt.Errorf("hold %s: %w", hold.ID, err))
+		}
+	}
+
+	if len(failed) > 0 {
+		return nil, fmt.Errorf("conference is cancelled but %d bookings or holds are not, cancel again to retry: %w", len(failed), errors.Join(failed...))
+	}
+	return conf, nil
+}
+
+// cancelForConference cancels one booking of a cancelled conference, refunding a paid seat in full.
+// It is released like any cancelled booking, see cancel.
+func (s *service) cancelForConference(ctx context.Context, booking *Booking, now time.Time) error {
+	p, err := s.findPool(ctx, booking)
+	if err != nil {
+		return err
+	}
+	if holdsSeat(booking) && booking.OrderID != "" {
+		refund, err := s.refundShare(ctx, booking, 100, now)
+		if err != nil {
+			return err
+		}
+		booking.Refund = refund
+	}
+	return s.cancel(ctx, p, booking)
+}
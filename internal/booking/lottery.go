@@ -0,0 +1,189 @@
+package booking
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	mathrand "math/rand"
+	"time"
+
+	"conference-booking/internal/conference"
+	apperrors "conference-booking/pkg/errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// drawnByScheduler marks draws run by the cleanup worker once registration has closed.
+const drawnByScheduler = "scheduler"
+
+var (
+	ErrLotteryClosed  = errors.New("lottery registration is not open")
+	ErrLotteryPending = errors.New("seats are allocated by lottery")
+)
+
+// enterLottery records a booking request as a lottery entry. It holds no seat until the draw.
+func (s *service) enterLottery(ctx context.Context, p *pool, req BookConferenceRequest, bookingID string) error {
+	if !p.conf.Lottery.Open(time.Now()) {
+		return ErrLotteryClosed
+	}
+	if err := s.redeemCode(ctx, p, 1); err != nil {
+		return err
+	}
+
+	return s.bookingRepo.Create(ctx, &Booking{
+		ID:           bookingID,
+		UserID:       req.UserID,
+		ConferenceID: p.conf.Name,
+		TicketType:   req.TicketType,
+		Code:         req.Code,
+		Accessible:   req.Accessible,
+		Status:       "LotteryEntry",
+		CreatedAt:    time.Now().UTC(),
+	})
+}
+
+// DrawLottery runs the lottery of a conference now, closing its registration. Only the owner may
+// trigger it; otherwise the cleanup worker draws once registration closes.
+func (s *service) DrawLottery(ctx context.Context, conferenceName string, req DrawRequest, requesterID string) (*Draw, error) {
+	ctx, span := tracer.Start(ctx, "booking.Service.DrawLottery", trace.WithAttributes(attribute.String("conference.id", conferenceName)))
+	defer span.End()
+
+	conf, err := s.confRepo.FindByName(ctx, conferenceName)
+	if err != nil {
+		return nil, err
+	}
+	if conf.OwnerID == "" || conf.OwnerID != requesterID {
+		return nil, apperrors.ErrForbidden
+	}
+
+	seed := randomSeed()
+	if req.Seed != nil {
+		seed = *req.Seed
+	}
+	return s.draw(ctx, conf, seed, requesterID)
+}
+
+// GetDraw returns the audit record of a conference's lottery draw.
+func (s *service) GetDraw(ctx context.Context, conferenceName string) (*Draw, error) {
+	ctx, span := tracer.Start(ctx, "booking.Service.GetDraw", trace.WithAttributes(attribute.String("conference.id", conferenceName)))
+	defer span.End()
+
+	return s.bookingRepo.FindDraw(ctx, conferenceName)
+}
+
+// drawDueLotteries draws every lottery whose registration has closed.
+func (s *service) drawDueLotteries(ctx context.Context, now time.Time) {
+	for _, conf := range s.confRepo.FindDueLotteries(ctx, now) {
+		_, _ = s.draw(ctx, conf, randomSeed(), drawnByScheduler)
+	}
+}
+
+// draw shuffles the entries of a pending lottery with the given seed. In drawn order, entries are
+// confirmed (or held for payment) while their pool has seats, and waitlisted until the start otherwise.
+// Entries whose user is blocked by the no-show policy are cancelled, and those that cannot be seated
+// (e.g. because the user has since booked an overlapping event) are waitlisted. An entry that fails
+// to update does not stop the draw; it is reported in the error and, with its reason, in the results.
+func (s *service) draw(ctx context.Context, conf *conference.Conference, seed int64, drawnBy string) (*Draw, error) {
+	// Claiming the draw closes registration, so no entry arrives and nobody else draws meanwhile
+	now := time.Now().UTC()
+	conf, err := s.confRepo.ClaimDraw(ctx, conf.Name, now)
+	if errors.Is(err, apperrors.ErrConflict) {
+		return nil, ErrInvalidAction
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	entries := s.bookingRepo.FindLotteryEntries(ctx, conf.Name)
+	draw := &Draw{ConferenceName: conf.Name, Seed: seed, Entries: []string{}, Results: []*DrawResult{}, DrawnBy: drawnBy, DrawnAt: now}
+	for _, entry := range entries {
+		draw.Entries = append(draw.Entries, entry.ID)
+	}
+	mathrand.New(mathrand.NewSource(seed)).Shuffle(len(entries), func(i, j int) {
+		entries[i], entries[j] = entries[j], entries[i]
+	})
+
+	var failed []error
+	for i, entry := range entries {
+		entry.LotteryRank = i + 1
+		result := &DrawResult{Rank: entry.LotteryRank, BookingID: entry.ID, UserID: entry.UserID}
+		if err := s.drawEntry(ctx, conf, entry); err != nil {
+			failed = append(failed, fmt.Errorf("entry %s: %w", entry.ID, err))
+			result.Status, result.Error = DrawFailed, err.Error()
+		} else {
+			result.Status = entry.Status
+		}
+		draw.Results = append(draw.Results, result)
+	}
+
+	if err := s.bookingRepo.CreateDraw(ctx, draw); err != nil {
+		return nil, err
+	}
+	if len(failed) > 0 {
+		return nil, fmt.Errorf("lottery drawn but %d entries were not updated: %w", len(failed), errors.Join(failed...))
+	}
+	return draw, nil
+}
+
+// drawEntry gives a drawn entry a seat while its pool has one and its user may take it, and
+// waitlists it until the start otherwise.
+func (s *service) drawEntry(ctx context.Context, conf *conference.Conference, entry *Booking) error {
+	p, err := s.findPool(ctx, entry)
+	if err != nil {
+		return err
+	}
+	u, err := s.userRepo.FindByID(ctx, entry.UserID)
+	if err != nil {
+		return err
+	}
+	if err := s.checkNoShows(u); err != nil {
+		entry.Status = "Canceled"
+		if err := s.bookingRepo.Update(ctx, entry); err != nil {
+			return err
+		}
+		if err := s.returnCodeUse(ctx, entry); err != nil {
+			return err
+		}
+		s.notify(ctx, entry.UserID, "Lottery entry cancelled", "Your entry "+entry.ID+" for "+conf.Name+" was cancelled: "+err.Error()+".")
+		return nil
+	}
+
+	if p.available() > 0 && s.checkOverlap(ctx, entry.UserID, p) == nil {
+		if seat, err := s.seats(ctx, p).pick("", entry.Accessible); err == nil {
+			entry.Seat = seat
+			entry.Status = "Confirmed"
+			if err := s.holdForPayment(ctx, p, entry.UserID, entry); err != nil {
+				return err
+			}
+			if err := s.bookingRepo.Update(ctx, entry); err != nil {
+				return err
+			}
+			if err := s.adjustSlots(ctx, p, -1); err != nil {
+				return err
+			}
+			s.notify(ctx, entry.UserID, "Lottery won", "Your entry "+entry.ID+" for "+conf.Name+" was drawn and has a seat.")
+			return nil
+		}
+	}
+
+	until := p.start()
+	entry.Status = "Waitlisted"
+	entry.WaitlistUntil = &until
+	if err := s.bookingRepo.Update(ctx, entry); err != nil {
+		return err
+	}
+	s.notify(ctx, entry.UserID, "Lottery waitlist", "Your entry "+entry.ID+" for "+conf.Name+" was not drawn for a seat and is on the waitlist.")
+	return nil
+}
+
+// randomSeed returns an unpredictable seed for draws that are not given one.
+func randomSeed() int64 {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return time.Now().UnixNano()
+	}
+	return int64(binary.BigEndian.Uint64(b[:]) >> 1)
+}
@@ -2,26 +2,303 @@ package booking
 
 import (
 	"time"
+
+	"conference-booking/internal/conference"
+	"conference-booking/internal/user"
 )
 
 type Booking struct {
-	ID            string
-	UserID        string
-	ConferenceID  string
-	Status        string
-	WaitlistUntil *time.Time
+	ID              string     `json:"id"`
+	UserID          string     `json:"user_id"`
+	ConferenceID    string     `json:"conference_id"`
+	SessionID       string     `json:"session_id,omitempty"`
+	TicketType      string     `json:"ticket_type,omitempty"`
+	GroupID         string     `json:"group_id,omitempty"`
+	BookerID        string     `json:"booker_id,omitempty"`
+	Status          string     `json:"status"`
+	WaitlistUntil   *time.Time `json:"waitlist_until,omitempty"`
+	OrderID         string     `json:"order_id,omitempty"`
+	Refund          *Refund    `json:"refund,omitempty"`
+	Code            string     `json:"code,omitempty"`
+	ReservedSeat    bool       `json:"reserved_seat,omitempty"`
+	Transfers       []Transfer `json:"transfers,omitempty"`
+	CheckedInAt     *time.Time `json:"checked_in_at,omitempty"`
+	LotteryRank     int        `json:"lottery_rank,omitempty"` // place in the lottery draw, 1 drawn first
+	Seat            string     `json:"seat,omitempty"`         // label in the conference's seat map
+	Accessible      bool       `json:"accessible,omitempty"`   // an accessible seat was asked for
+	SeriesBookingID string     `json:"series_booking_id,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// Transfer records one change of the holder of a booking.
+type Transfer struct {
+	FromUserID    string    `json:"from_user_id"`
+	ToUserID      string    `json:"to_user_id"`
+	TransferredAt time.Time `json:"transferred_at"`
+}
+
+// WaitlistPosition is where a waitlisted booking stands in the promotion order of its pool.
+type WaitlistPosition struct {
+	BookingID string `json:"booking_id"`
+	Tier      string `json:"tier"`
+	Position  int    `json:"position"` // 1 is promoted next
+	Length    int    `json:"length"`
+}
+
+type TransferBookingRequest struct {
+	ToUserID string `json:"to_user_id"`
 }
 
 type BookConferenceRequest struct {
 	ConferenceName string `json:"conference_name"`
 	UserID         string `json:"user_id"`
+	SessionID      string `json:"session_id,omitempty"`
+	TicketType     string `json:"ticket_type,omitempty"`
+	Code           string `json:"code,omitempty"`
+	Seat           string `json:"seat,omitempty"`
+	Accessible     bool   `json:"accessible,omitempty"`
+	AllowOverlap   bool   `json:"allow_overlap,omitempty"`
+}
+
+// BookGroupRequest reserves several seats for one booker. Seats are assigned to attendees later.
+type BookGroupRequest struct {
+	ConferenceName string `json:"conference_name"`
+	BookerID       string `json:"booker_id"`
+	SessionID      string `json:"session_id,omitempty"`
+	TicketType     string `json:"ticket_type,omitempty"`
+	Code           string `json:"code,omitempty"`
+	Seats          int    `json:"seats"`
+}
+
+type AssignSeatRequest struct {
+	BookingID    string `json:"booking_id"`
+	UserID       string `json:"user_id"`
+	AllowOverlap bool   `json:"allow_overlap,omitempty"`
+}
+
+// GroupBooking is a set of seats booked together. Each seat is a Booking sharing the group ID;
+// seats that did not fit in the remaining capacity are waitlisted.
+type GroupBooking struct {
+	ID             string     `json:"id"`
+	ConferenceID   string     `json:"conference_id"`
+	SessionID      string     `json:"session_id,omitempty"`
+	TicketType     string     `json:"ticket_type,omitempty"`
+	BookerID       string     `json:"booker_id"`
+	Confirmed      int        `json:"confirmed"`
+	PendingPayment int        `json:"pending_payment"`
+	Waitlisted     int        `json:"waitlisted"`
+	Seats          []*Booking `json:"seats"`
 }
 
 type ConfirmWaitlistRequest struct {
-	BookingID string `json:"booking_id"`
+	BookingID    string `json:"booking_id"`
+	AllowOverlap bool   `json:"allow_overlap,omitempty"`
 }
 
 type BookingStatus struct {
 	Status        string     `json:"status"`
 	WaitlistUntil *time.Time `json:"waitlist_until,omitempty"`
+	OrderID       string     `json:"order_id,omitempty"`
+	Refund        *Refund    `json:"refund,omitempty"`
+	Seat          string     `json:"seat,omitempty"`
+}
+
+// SeatAvailability is the seat map of a conference with the availability of each seat.
+type SeatAvailability struct {
+	Conference string                 `json:"conference"`
+	Total      int                    `json:"total"`
+	Available  int                    `json:"available"`
+	Rows       []*SeatRowAvailability `json:"rows"`
+}
+
+type SeatRowAvailability struct {
+	Label string       `json:"label"`
+	Seats []*SeatState `json:"seats"`
+}
+
+type SeatState struct {
+	Label      string `json:"label"`
+	Accessible bool   `json:"accessible,omitempty"`
+	Available  bool   `json:"available"`
+}
+
+const (
+	OrderPending   = "Pending"
+	OrderPaying    = "Paying" // being charged; neither expires nor closes meanwhile
+	OrderPaid      = "Paid"
+	OrderFailed    = "Failed"
+	OrderExpired   = "Expired"
+	OrderCancelled = "Cancelled"
+)
+
+// Hold statuses
+const (
+	HoldActive    = "Active"
+	HoldCommitted = "Committed"
+	HoldExpired   = "Expired"
+)
+
+// SeriesBooking books a user into every future occurrence of a conference series, including
+// occurrences generated after it was made.
+type SeriesBooking struct {
+	ID           string            `json:"id"`
+	SeriesID     string            `json:"series_id"`
+	UserID       string            `json:"user_id"`
+	AllowOverlap bool              `json:"allow_overlap,omitempty"`
+	Occurrences  map[string]string `json:"occurrences"` // conference name to booking ID
+	CreatedAt    time.Time         `json:"created_at"`
+	CancelledAt  *time.Time        `json:"cancelled_at,omitempty"`
+}
+
+type BookSeriesRequest struct {
+	SeriesID     string `json:"series_id"`
+	UserID       string `json:"user_id"`
+	AllowOverlap bool   `json:"allow_overlap,omitempty"`
+}
+
+// Hold keeps a seat off the market for a user until ExpiresAt, e.g. while they fill in a checkout
+// form. Committing it turns it into a booking; an expired hold returns its seat to the pool.
+type Hold struct {
+	ID             string    `json:"id"`
+	UserID         string    `json:"user_id"`
+	ConferenceName string    `json:"conference_name"`
+	SessionID      string    `json:"session_id,omitempty"`
+	TicketType     string    `json:"ticket_type,omitempty"`
+	Code           string    `json:"code,omitempty"`
+	Seat           string    `json:"seat,omitempty"`
+	Accessible     bool      `json:"accessible,omitempty"`
+	Status         string    `json:"status"`
+	BookingID      string    `json:"booking_id,omitempty"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type HoldSeatRequest struct {
+	ConferenceName string `json:"conference_name"`
+	UserID         string `json:"user_id"`
+	SessionID      string `json:"session_id,omitempty"`
+	TicketType     string `json:"ticket_type,omitempty"`
+	Code           string `json:"code,omitempty"`
+	Seat           string `json:"seat,omitempty"`
+	Accessible     bool   `json:"accessible,omitempty"`
+}
+
+type CommitHoldRequest struct {
+	AllowOverlap bool `json:"allow_overlap,omitempty"`
+}
+
+// Draw is the audit record of a lottery draw. Entries lists the entry bookings in entry order
+// (creation time, then ID); shuffling them with math/rand's Shuffle on a source seeded with Seed
+// reproduces the order of Results.
+type Draw struct {
+	ConferenceName string        `json:"conference_name"`
+	Seed           int64         `json:"seed"`
+	Entries        []string      `json:"entries"`
+	Results        []*DrawResult `json:"results"`
+	DrawnBy        string        `json:"drawn_by"`
+	DrawnAt        time.Time     `json:"drawn_at"`
+}
+
+// DrawResult is the outcome of one lottery entry. An entry that could not be updated has the
+// status DrawFailed and the reason in Error.
+type DrawResult struct {
+	Rank      int    `json:"rank"`
+	BookingID string `json:"booking_id"`
+	UserID    string `json:"user_id"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+}
+
+// DrawFailed is the result status of a drawn entry that could not be updated.
+const DrawFailed = "Failed"
+
+// DrawRequest triggers a lottery draw. A seed is generated when none is given.
+type DrawRequest struct {
+	Seed *int64 `json:"seed,omitempty"`
+}
+
+// Order is the payment for one or more seats of a priced ticket type. While it is pending
+// its bookings are PendingPayment and hold their seats until ExpiresAt.
+type Order struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"user_id"`
+	BookingIDs []string   `json:"booking_ids"`
+	Amount     int64      `json:"amount"` // minor units of Currency
+	Currency   string     `json:"currency"`
+	Status     string     `json:"status"`
+	ChargeID   string     `json:"charge_id,omitempty"`
+	Refunded   int64      `json:"refunded,omitempty"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	PaidAt     *time.Time `json:"paid_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// Refund records the money returned for one cancelled seat of a paid order,
+// as computed by the conference's cancellation policy.
+type Refund struct {
+	OrderID   string    `json:"order_id"`
+	Percent   int       `json:"percent"`
+	Amount    int64     `json:"amount"` // minor units of Currency
+	Currency  string    `json:"currency"`
+	RefundID  string    `json:"refund_id,omitempty"` // reference of the payment provider
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type PayOrderRequest struct {
+	Token string `json:"token"`
+}
+
+type CheckInRequest struct {
+	Token string `json:"token"`
+}
+
+// Attendance counts checked-in bookings against booked seats, for the conference as a whole
+// and for each of its sessions.
+type Attendance struct {
+	Conference string               `json:"conference"`
+	Booked     int                  `json:"booked"`
+	Attended   int                  `json:"attended"`
+	Sessions   []*SessionAttendance `json:"sessions"`
+}
+
+type SessionAttendance struct {
+	SessionID string `json:"session_id"`
+	Booked    int    `json:"booked"`
+	Attended  int    `json:"attended"`
+}
+
+// Attendee is a booking joined with the details of the user who holds it.
+type Attendee struct {
+	BookingID     string     `json:"booking_id"`
+	SessionID     string     `json:"session_id,omitempty"`
+	TicketType    string     `json:"ticket_type,omitempty"`
+	Status        string     `json:"status"`
+	WaitlistUntil *time.Time `json:"waitlist_until,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	User          *user.User `json:"user"`
+}
+
+// Roster groups every booking of a conference by state for its organiser.
+type Roster struct {
+	Conference     string      `json:"conference"`
+	Confirmed      []*Attendee `json:"confirmed"`
+	Attended       []*Attendee `json:"attended"`
+	PendingPayment []*Attendee `json:"pending_payment"`
+	Waitlisted     []*Attendee `json:"waitlisted"`
+	Cancelled      []*Attendee `json:"cancelled"`
+}
+
+// SeatHolders returns the attendees holding a seat: confirmed, checked in or awaiting payment.
+func (r *Roster) SeatHolders() []*Attendee {
+	holders := make([]*Attendee, 0, len(r.Confirmed)+len(r.Attended)+len(r.PendingPayment))
+	holders = append(holders, r.Confirmed...)
+	holders = append(holders, r.Attended...)
+	return append(holders, r.PendingPayment...)
+}
+
+// CalendarEntry is a confirmed booking together with its conference, for calendar feeds.
+type CalendarEntry struct {
+	BookingID  string
+	Conference *conference.Conference
+	Session    *conference.Session
 }
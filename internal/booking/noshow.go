@@ -0,0 +1,47 @@
+package booking
+
+import (
+	"context"
+	"errors"
+
+	"conference-booking/internal/user"
+)
+
+// NoShowRules is the policy for users who book and never check in. A zero threshold disables its rule.
+type NoShowRules struct {
+	DeprioritizeAfter int // users with at least this many no-shows are promoted from waitlists last
+	BlockAfter        int // users with at least this many no-shows cannot book
+}
+
+var ErrTooManyNoShows = errors.New("booking blocked after repeated no-shows")
+
+func (r NoShowRules) blocks(u *user.User) bool {
+	return r.BlockAfter > 0 && u.NoShows >= r.BlockAfter
+}
+
+func (r NoShowRules) deprioritizes(u *user.User) bool {
+	return r.DeprioritizeAfter > 0 && u.NoShows >= r.DeprioritizeAfter
+}
+
+// checkNoShows rejects users blocked by the no-show policy.
+func (s *service) checkNoShows(u *user.User) error {
+	if s.cfg.NoShows.blocks(u) {
+		return ErrTooManyNoShows
+	}
+	return nil
+}
+
+// markNoShow records that a confirmed booking was never checked in and counts it against its holder.
+func (s *service) markNoShow(ctx context.Context, booking *Booking) error {
+	booking.Status = "NoShow"
+	if err := s.bookingRepo.Update(ctx, booking); err != nil {
+		return err
+	}
+
+	u, err := s.userRepo.FindByID(ctx, booking.UserID)
+	if err != nil {
+		return err
+	}
+	u.NoShows++
+	return s.userRepo.Update(ctx, u)
+}
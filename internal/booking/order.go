@@ -0,0 +1,229 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"conference-booking/internal/payment"
+	apperrors "conference-booking/pkg/errors"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	ErrPaymentExpired = errors.New("payment deadline passed")
+	ErrOrderCancelled = errors.New("the order's bookings were cancelled during payment; the charge was refunded")
+)
+
+// holdForPayment opens one order for seats of a priced pool and marks them PendingPayment.
+// The seats stay counted as taken until the order is paid, fails or expires.
+// Free pools are left untouched, so their bookings are confirmed straight away.
+func (s *service) holdForPayment(ctx context.Context, p *pool, payerID string, bookings ...*Booking) error {
+	price, currency := p.price()
+	if price == 0 || len(bookings) == 0 {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	order := &Order{
+		ID:        uuid.New().String(),
+		UserID:    payerID,
+		Amount:    price * int64(len(bookings)),
+		Currency:  currency,
+		Status:    OrderPending,
+		ExpiresAt: now.Add(s.cfg.PaymentHold),
+		CreatedAt: now,
+	}
+	for _, booking := range bookings {
+		booking.Status = "PendingPayment"
+		booking.OrderID = order.ID
+		order.BookingIDs = append(order.BookingIDs, booking.ID)
+	}
+	return s.bookingRepo.CreateOrder(ctx, order)
+}
+
+// GetOrder returns an order. Only the paying user may see it.
+func (s *service) GetOrder(ctx context.Context, orderID, requesterID string) (*Order, error) {
+	ctx, span := tracer.Start(ctx, "booking.Service.GetOrder", trace.WithAttributes(attribute.String("order.id", orderID)))
+	defer span.End()
+
+	order, err := s.bookingRepo.FindOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order.UserID != requesterID {
+		return nil, apperrors.ErrForbidden
+	}
+	return order, nil
+}
+
+// PayOrder charges a pending order through the payment provider. On success its bookings are
+// confirmed; on failure the order is closed and its seats are released to the waitlist.
+// The order is claimed before the charge, so it is charged at most once and cannot expire or
+// close meanwhile. Seats cancelled while the charge was in flight are refunded in full.
+func (s *service) PayOrder(ctx context.Context, orderID string, req PayOrderRequest, requesterID string) (*Order, error) {
+	ctx, span := tracer.Start(ctx, "booking.Service.PayOrder", trace.WithAttributes(attribute.String("order.id", orderID)))
+	defer span.End()
+
+	order, err := s.GetOrder(ctx, orderID, requesterID)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != OrderPending {
+		return nil, ErrInvalidAction
+	}
+	if !order.ExpiresAt.After(time.Now()) {
+		if err := s.releaseOrder(ctx, order, OrderPending, OrderExpired); err != nil {
+			return nil, err
+		}
+		return nil, ErrPaymentExpired
+	}
+	if err := s.bookingRepo.UpdateOrderStatus(ctx, order.ID, OrderPending, OrderPaying); err != nil {
+		if errors.Is(err, apperrors.ErrConflict) {
+			return nil, ErrInvalidAction
+		}
+		return nil, err
+	}
+
+	charge, err := s.payments.Charge(ctx, payment.ChargeRequest{
+		OrderID:  order.ID,
+		Amount:   order.Amount,
+		Currency: order.Currency,
+		Token:    req.Token,
+	})
+	if err != nil {
+		if releaseErr := s.releaseOrder(ctx, order, OrderPaying, OrderFailed); releaseErr != nil {
+			return nil, releaseErr
+		}
+		return nil, err
+	}
+
+	// Confirm the bookings still waiting for this payment
+	confirmed := s.bookingRepo.ConfirmPendingPayment(ctx, order.BookingIDs)
+	paidAt := time.Now().UTC()
+	order.ChargeID = charge.ID
+	order.PaidAt = &paidAt
+	if err := s.bookingRepo.UpdateOrder(ctx, order); err != nil {
+		return nil, err
+	}
+	if err := s.bookingRepo.UpdateOrderStatus(ctx, order.ID, OrderPaying, OrderPaid); err != nil {
+		return nil, err
+	}
+
+	// Refund the seats cancelled while the order was open
+	for _, bookingID := range order.BookingIDs {
+		if confirmed[bookingID] {
+			continue
+		}
+		booking, err := s.bookingRepo.FindByID(ctx, bookingID)
+		if err != nil {
+			return nil, err
+		}
+		if booking.Refund, err = s.refundShare(ctx, booking, 100, paidAt); err != nil {
+			return nil, err
+		}
+		if err := s.bookingRepo.Update(ctx, booking); err != nil {
+			return nil, err
+		}
+	}
+	if len(confirmed) == 0 {
+		if err := s.bookingRepo.UpdateOrderStatus(ctx, order.ID, OrderPaid, OrderCancelled); err != nil {
+			return nil, err
+		}
+		return nil, ErrOrderCancelled
+	}
+	return order, nil
+}
+
+// releaseOrder closes an unpaid order, moving it from status from to status, and cancels its
+// bookings, which hands their seats to the waitlist. It fails with ErrConflict when the order
+// left status from meanwhile.
+func (s *service) releaseOrder(ctx context.Context, order *Order, from, status string) error {
+	if err := s.bookingRepo.UpdateOrderStatus(ctx, order.ID, from, status); err != nil {
+		return err
+	}
+
+	for _, bookingID := range order.BookingIDs {
+		booking, err := s.bookingRepo.FindByID(ctx, bookingID)
+		if err != nil {
+			return err
+		}
+		if booking.Status != "PendingPayment" {
+			continue
+		}
+		p, err := s.findPool(ctx, booking)
+		if err != nil {
+			return err
+		}
+		if err := s.cancel(ctx, p, booking); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// closeOrderIfEmpty cancels a pending order once none of its bookings is waiting for payment.
+// Orders being paid are left to PayOrder, which refunds their cancelled seats.
+func (s *service) closeOrderIfEmpty(ctx context.Context, orderID string) error {
+	order, err := s.bookingRepo.FindOrder(ctx, orderID)
+	if err != nil || order.Status != OrderPending {
+		return err
+	}
+
+	for _, bookingID := range order.BookingIDs {
+		if booking, err := s.bookingRepo.FindByID(ctx, bookingID); err == nil && booking.Status == "PendingPayment" {
+			return nil
+		}
+	}
+	if err := s.bookingRepo.UpdateOrderStatus(ctx, order.ID, OrderPending, OrderCancelled); err != nil && !errors.Is(err, apperrors.ErrConflict) {
+		return err
+	}
+	return nil
+}
+
+// refund computes the refund of one paid seat under the conference's cancellation policy and
+// returns the money through the payment provider. Seats of unpaid orders get no refund.
+func (s *service) refund(ctx context.Context, p *pool, booking *Booking, now time.Time) (*Refund, error) {
+	return s.refundShare(ctx, booking, p.conf.CancellationPolicy.RefundPercent(p.start(), now), now)
+}
+
+// refundShare returns percent of the price of one paid seat through the payment provider.
+func (s *service) refundShare(ctx context.Context, booking *Booking, percent int, now time.Time) (*Refund, error) {
+	order, err := s.bookingRepo.FindOrder(ctx, booking.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	if order.Status != OrderPaid {
+		return nil, nil
+	}
+
+	refund := &Refund{
+		OrderID:   order.ID,
+		Percent:   percent,
+		Amount:    order.Amount / int64(len(order.BookingIDs)) * int64(percent) / 100,
+		Currency:  order.Currency,
+		CreatedAt: now.UTC(),
+	}
+	if refund.Amount == 0 {
+		return refund, nil
+	}
+
+	result, err := s.payments.Refund(ctx, payment.RefundRequest{
+		ChargeID: order.ChargeID,
+		Amount:   refund.Amount,
+		Currency: order.Currency,
+	})
+	if err != nil {
+		return nil, err
+	}
+	refund.RefundID = result.ID
+
+	order.Refunded += refund.Amount
+	if err := s.bookingRepo.UpdateOrder(ctx, order); err != nil {
+		return nil, err
+	}
+	return refund, nil
+}
@@ -0,0 +1,211 @@
+package booking
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"conference-booking/internal/conference"
+	apperrors "conference-booking/pkg/errors"
+)
+
+// pool is the seat counter a booking draws from: a session, a ticket type, or the conference itself.
+// Each pool has its own availability and its own waitlist. A booking made with a promo code that
+// reserves seats draws from the code's reserve instead, which has no waitlist.
+type pool struct {
+	conf       *conference.Conference
+	session    *conference.Session
+	ticketType *conference.TicketType
+	code       *conference.PromoCode
+	reserved   bool
+}
+
+func (p *pool) available() int {
+	switch {
+	case p.reserved:
+		return p.code.AvailableSlots
+	case p.session != nil:
+		return p.session.AvailableSlots
+	case p.ticketType != nil:
+		return p.ticketType.AvailableSlots
+	default:
+		return p.conf.AvailableSlots
+	}
+}
+
+// capacity returns the seats of the pool in total. Conferences stored without a capacity report
+// their free seats.
+func (p *pool) capacity() int {
+	switch {
+	case p.reserved:
+		return p.code.ReservedSeats
+	case p.session != nil:
+		return p.session.Capacity
+	case p.ticketType != nil:
+		return p.ticketType.Capacity
+	default:
+		return max(p.conf.Capacity, p.conf.AvailableSlots)
+	}
+}
+
+// start returns when the event behind the pool begins.
+func (p *pool) start() time.Time {
+	if p.session != nil {
+		return p.session.StartTime
+	}
+	return p.conf.StartTime
+}
+
+// end returns when the event behind the pool finishes.
+func (p *pool) end() time.Time {
+	if p.session != nil {
+		return p.session.EndTime
+	}
+	return p.conf.EndTime
+}
+
+// price returns the price of one seat in minor units of its currency.
+// Only ticket types carry a price; sessions and plain conferences are free.
+func (p *pool) price() (int64, string) {
+	if p.ticketType == nil {
+		return 0, ""
+	}
+	price := p.ticketType.Price
+	if p.code != nil {
+		price = price * int64(100-p.code.DiscountPercent) / 100
+	}
+	return price, p.ticketType.Currency
+}
+
+// resolvePool finds the pool a new booking would draw from. Conferences with ticket types
+// sell whole-conference seats by type only, and only while the type is on sale.
+// Invite-only conferences require a promo code, which must belong to the conference.
+func (s *service) resolvePool(ctx context.Context, conferenceName, sessionID, ticketType, code string) (*pool, error) {
+	conf, err := s.confRepo.FindByName(ctx, conferenceName)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkRegistration(conf, time.Now()); err != nil {
+		return nil, err
+	}
+	s.releaseExpiredHolds(ctx, conf.Name, time.Now())
+	p := &pool{conf: conf}
+
+	switch {
+	case sessionID != "" && ticketType != "":
+		return nil, fmt.Errorf("%w: session_id and ticket_type cannot be combined", apperrors.ErrInvalidInput)
+
+	case sessionID != "":
+		p.session, err = s.confRepo.FindSession(ctx, sessionID)
+		if err != nil {
+			return nil, err
+		}
+		if p.session.ConferenceName != conf.Name {
+			return nil, apperrors.ErrNotFound
+		}
+
+	case ticketType != "":
+		p.ticketType, err = s.confRepo.FindTicketType(ctx, conf.Name, ticketType)
+		if err != nil {
+			return nil, err
+		}
+		if !p.ticketType.OnSale(time.Now()) {
+			return nil, ErrTicketSaleClosed
+		}
+
+	default:
+		if len(s.confRepo.FindTicketTypes(ctx, conf.Name)) > 0 {
+			return nil, fmt.Errorf("%w: ticket_type is required for this conference", apperrors.ErrInvalidInput)
+		}
+	}
+
+	if code == "" {
+		if conf.InviteOnly {
+			return nil, ErrInviteRequired
+		}
+		return p, nil
+	}
+	p.code, err = s.confRepo.FindPromoCode(ctx, conf.Name, code)
+	if err != nil || (p.code.TicketType != "" && p.code.TicketType != ticketType) {
+		return nil, ErrInvalidCode
+	}
+	return p, nil
+}
+
+// findPool returns the pool an existing booking draws from.
+func (s *service) findPool(ctx context.Context, booking *Booking) (*pool, error) {
+	conf, err := s.confRepo.FindByName(ctx, booking.ConferenceID)
+	if err != nil {
+		return nil, err
+	}
+	p := &pool{conf: conf}
+
+	switch {
+	case booking.SessionID != "":
+		p.session, err = s.confRepo.FindSession(ctx, booking.SessionID)
+	case booking.TicketType != "":
+		p.ticketType, err = s.confRepo.FindTicketType(ctx, conf.Name, booking.TicketType)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if booking.Code != "" {
+		if p.code, err = s.confRepo.FindPromoCode(ctx, conf.Name, booking.Code); err != nil {
+			return nil, err
+		}
+		p.reserved = booking.ReservedSeat
+	}
+	return p, nil
+}
+
+// adjustSlots changes the free seats of a pool.
+func (s *service) adjustSlots(ctx context.Context, p *pool, delta int) error {
+	switch {
+	case p.reserved:
+		p.code.AvailableSlots += delta
+		return s.confRepo.UpdatePromoCode(ctx, p.code)
+	case p.session != nil:
+		p.session.AvailableSlots += delta
+		return s.confRepo.UpdateSession(ctx, p.session)
+	case p.ticketType != nil:
+		p.ticketType.AvailableSlots += delta
+		return s.confRepo.UpdateTicketType(ctx, p.ticketType)
+	default:
+		p.conf.AvailableSlots += delta
+		return s.confRepo.Update(ctx, p.conf)
+	}
+}
+
+// waitlist returns the waitlist of a pool in promotion order: by tier, then joining order.
+func (s *service) waitlist(ctx context.Context, p *pool) []*Booking {
+	var waitlist []*Booking
+	switch {
+	case p.reserved:
+		return nil
+	case p.session != nil:
+		waitlist = s.bookingRepo.FindWaitlistForSession(ctx, p.session.ID)
+	case p.ticketType != nil:
+		waitlist = s.bookingRepo.FindWaitlistForTicketType(ctx, p.conf.Name, p.ticketType.Name)
+	default:
+		waitlist = s.bookingRepo.FindWaitlistForConference(ctx, p.conf.Name)
+	}
+	return s.prioritize(ctx, waitlist)
+}
+
+// checkOverlap returns an OverlapError when the user already holds a seat at the same time, in a
+// conference or a session. Sessions do not clash with a booking of their own conference.
+func (s *service) checkOverlap(ctx context.Context, userID string, p *pool) error {
+	sessionID := ""
+	if p.session != nil {
+		sessionID = p.session.ID
+	}
+	conflicting, err := s.bookingRepo.FindOverlappingBooking(ctx, userID, p.conf.Name, sessionID, p.start(), p.end())
+	if err != nil {
+		return err
+	}
+	if conflicting != nil {
+		return &OverlapError{Conflicting: conflicting}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package booking
+
+import (
+	"context"
+	"time"
+)
+
+// redeemCode counts uses of the pool's promo code, if any, and fails when the code has expired
+// or has too few uses left.
+func (s *service) redeemCode(ctx context.Context, p *pool, uses int) error {
+	if p.code == nil {
+		return nil
+	}
+	if !p.code.Usable(time.Now(), uses) {
+		return ErrInvalidCode
+	}
+
+	p.code.Uses += uses
+	return s.confRepo.UpdatePromoCode(ctx, p.code)
+}
+
+// returnCodeUse gives back the use of a promo code taken by a booking that ends while waiting for
+// a seat (waitlisted, promoted but not confirmed, or a lottery entry). Uses are only kept by
+// bookings that got a seat.
+func (s *service) returnCodeUse(ctx context.Context, booking *Booking) error {
+	if booking.Code == "" {
+		return nil
+	}
+	code, err := s.confRepo.FindPromoCode(ctx, booking.ConferenceID, booking.Code)
+	if err != nil {
+		return err
+	}
+	code.Uses = max(code.Uses-1, 0)
+	return s.confRepo.UpdatePromoCode(ctx, code)
+}
+
+// waitsForSeat reports whether a booking used a code use without having had a seat yet.
+func waitsForSeat(booking *Booking) bool {
+	switch booking.Status {
+	case "Waitlisted", "PendingConfirmation", "LotteryEntry":
+		return true
+	}
+	return false
+}
+
+// releaseExpiredReservations hands the untaken reserved seats of expired codes back to the
+// ticket type or conference they were set aside from.
+func (s *service) releaseExpiredReservations(ctx context.Context, now time.Time) {
+	for _, code := range s.confRepo.FindExpiredReservations(ctx, now) {
+		conf, err := s.confRepo.FindByName(ctx, code.ConferenceName)
+		if err != nil {
+			continue // Skip if conference not found
+		}
+		p := &pool{conf: conf}
+		if code.TicketType != "" {
+			if p.ticketType, err = s.confRepo.FindTicketType(ctx, conf.Name, code.TicketType); err != nil {
+				continue // Skip if ticket type not found
+			}
+		}
+
+		if err := s.adjustSlots(ctx, p, code.AvailableSlots); err != nil {
+			continue
+		}
+		code.AvailableSlots = 0
+		_ = s.confRepo.UpdatePromoCode(ctx, code)
+	}
+}
@@ -3,25 +3,62 @@ package booking
 import (
 	"conference-booking/internal/conference"
 	"conference-booking/pkg/errors"
+	"conference-booking/pkg/query"
+	"context"
+	"sort"
 	"sync"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Repository interface {
-	Create(booking *Booking) error
-	FindByID(id string) (*Booking, error)
-	FindByUserAndConference(userID, conferenceID string) (*Booking, error)
-	Update(booking *Booking) error
-	Cancel(bookingID string) error
-	FindWaitlistForConference(conferenceID string) []*Booking
-	FindActiveBooking(userID, conferenceID string) (*Booking, error)
-	RemoveOverlappingWaitlists(userID string, start, end time.Time) error
-	HasOverlappingConfirmedBookings(userID string, start, end time.Time) (bool, error)
-	GetAllBookings() []*Booking
+	Create(ctx context.Context, booking *Booking) error
+	FindByID(ctx context.Context, id string) (*Booking, error)
+	FindByUserAndConference(ctx context.Context, userID, conferenceID string) (*Booking, error)
+	Update(ctx context.Context, booking *Booking) error
+	Cancel(ctx context.Context, bookingID string) error
+	FindWaitlistForConference(ctx context.Context, conferenceID string) []*Booking
+	FindWaitlistForSession(ctx context.Context, sessionID string) []*Booking
+	FindWaitlistForTicketType(ctx context.Context, conferenceID, ticketType string) []*Booking
+	FindByConference(ctx context.Context, conferenceID string) []*Booking
+	FindByGroup(ctx context.Context, groupID string) []*Booking
+	FindActiveBooking(ctx context.Context, userID, conferenceID, sessionID string) (*Booking, error)
+	RemoveOverlappingWaitlists(ctx context.Context, userID string, start, end time.Time) []*Booking
+	FindOverlappingBooking(ctx context.Context, userID, conferenceID, sessionID string, start, end time.Time) (*Booking, error)
+	Transfer(ctx context.Context, bookingID, fromUserID, toUserID string, start, end, at time.Time) (*Booking, error)
+	CheckIn(ctx context.Context, bookingID string, at time.Time) error
+	GetAllBookings(ctx context.Context) []*Booking
+	List(ctx context.Context, q query.Query) (query.Page[*Booking], error)
+	CreateOrder(ctx context.Context, order *Order) error
+	FindOrder(ctx context.Context, id string) (*Order, error)
+	UpdateOrder(ctx context.Context, order *Order) error
+	UpdateOrderStatus(ctx context.Context, orderID, from, to string) error
+	ConfirmPendingPayment(ctx context.Context, bookingIDs []string) map[string]bool
+	FindExpiredOrders(ctx context.Context, now time.Time) []*Order
+	FindLotteryEntries(ctx context.Context, conferenceID string) []*Booking
+	CreateHold(ctx context.Context, hold *Hold) error
+	FindHold(ctx context.Context, id string) (*Hold, error)
+	FindActiveHold(ctx context.Context, userID, conferenceID, sessionID string) (*Hold, error)
+	FindActiveHolds(ctx context.Context, conferenceID string) []*Hold
+	UpdateHold(ctx context.Context, hold *Hold) error
+	UpdateHoldStatus(ctx context.Context, holdID, from, to string) error
+	FindExpiredHolds(ctx context.Context, now time.Time) []*Hold
+	CreateSeriesBooking(ctx context.Context, seriesBooking *SeriesBooking) error
+	FindSeriesBooking(ctx context.Context, id string) (*SeriesBooking, error)
+	UpdateSeriesBooking(ctx context.Context, seriesBooking *SeriesBooking) error
+	ListSeriesBookings(ctx context.Context) []*SeriesBooking
+	CreateDraw(ctx context.Context, draw *Draw) error
+	FindDraw(ctx context.Context, conferenceID string) (*Draw, error)
 }
 
 type inMemoryRepository struct {
 	bookings       map[string]*Booking
+	orders         map[string]*Order
+	holds          map[string]*Hold
+	seriesBookings map[string]*SeriesBooking
+	draws          map[string]*Draw // keyed by conference name
 	mutex          sync.Mutex
 	conferenceRepo conference.Repository
 }
@@ -29,11 +66,18 @@ type inMemoryRepository struct {
 func NewInMemoryRepository(confRepo conference.Repository) Repository {
 	return &inMemoryRepository{
 		bookings:       make(map[string]*Booking),
+		orders:         make(map[string]*Order),
+		holds:          make(map[string]*Hold),
+		seriesBookings: make(map[string]*SeriesBooking),
+		draws:          make(map[string]*Draw),
 		conferenceRepo: confRepo,
 	}
 }
 
-func (r *inMemoryRepository) Create(booking *Booking) error {
+func (r *inMemoryRepository) Create(ctx context.Context, booking *Booking) error {
+	_, span := tracer.Start(ctx, "booking.Repository.Create", trace.WithAttributes(attribute.String("booking.id", booking.ID), attribute.String("conference.id", booking.ConferenceID)))
+	defer span.End()
+
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
@@ -41,7 +85,10 @@ func (r *inMemoryRepository) Create(booking *Booking) error {
 	return nil
 }
 
-func (r *inMemoryRepository) FindByID(id string) (*Booking, error) {
+func (r *inMemoryRepository) FindByID(ctx context.Context, id string) (*Booking, error) {
+	_, span := tracer.Start(ctx, "booking.Repository.FindByID", trace.WithAttributes(attribute.String("booking.id", id)))
+	defer span.End()
+
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
@@ -52,7 +99,10 @@ func (r *inMemoryRepository) FindByID(id string) (*Booking, error) {
 	return booking, nil
 }
 
-func (r *inMemoryRepository) FindByUserAndConference(userID, conferenceID string) (*Booking, error) {
+func (r *inMemoryRepository) FindByUserAndConference(ctx context.Context, userID, conferenceID string) (*Booking, error) {
+	_, span := tracer.Start(ctx, "booking.Repository.FindByUserAndConference", trace.WithAttributes(attribute.String("user.id", userID), attribute.String("conference.id", conferenceID)))
+	defer span.End()
+
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
@@ -64,7 +114,10 @@ func (r *inMemoryRepository) FindByUserAndConference(userID, conferenceID string
 	return nil, nil
 }
 
-func (r *inMemoryRepository) Update(booking *Booking) error {
+func (r *inMemoryRepository) Update(ctx context.Context, booking *Booking) error {
+	_, span := tracer.Start(ctx, "booking.Repository.Update", trace.WithAttributes(attribute.String("booking.id", booking.ID), attribute.String("conference.id", booking.ConferenceID)))
+	defer span.End()
+
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
@@ -72,7 +125,10 @@ func (r *inMemoryRepository) Update(booking *Booking) error {
 	return nil
 }
 
-func (r *inMemoryRepository) Cancel(bookingID string) error {
+func (r *inMemoryRepository) Cancel(ctx context.Context, bookingID string) error {
+	_, span := tracer.Start(ctx, "booking.Repository.Cancel", trace.WithAttributes(attribute.String("booking.id", bookingID)))
+	defer span.End()
+
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
@@ -84,75 +140,246 @@ func (r *inMemoryRepository) Cancel(bookingID string) error {
 	return errors.ErrNotFound
 }
 
-func (r *inMemoryRepository) FindWaitlistForConference(conferenceID string) []*Booking {
+func (r *inMemoryRepository) FindWaitlistForConference(ctx context.Context, conferenceID string) []*Booking {
+	_, span := tracer.Start(ctx, "booking.Repository.FindWaitlistForConference", trace.WithAttributes(attribute.String("conference.id", conferenceID)))
+	defer span.End()
+
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
 	var waitlist []*Booking
 	for _, booking := range r.bookings {
-		if booking.ConferenceID == conferenceID && booking.Status == "Waitlisted" {
+		if booking.ConferenceID == conferenceID && booking.SessionID == "" && booking.TicketType == "" && booking.Status == "Waitlisted" {
 			waitlist = append(waitlist, booking)
 		}
 	}
+	sortByCreation(waitlist)
 	return waitlist
 }
 
+// FindWaitlistForSession returns the waitlist of a single session in joining order.
+func (r *inMemoryRepository) FindWaitlistForSession(ctx context.Context, sessionID string) []*Booking {
+	_, span := tracer.Start(ctx, "booking.Repository.FindWaitlistForSession", trace.WithAttributes(attribute.String("session.id", sessionID)))
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var waitlist []*Booking
+	for _, booking := range r.bookings {
+		if booking.SessionID == sessionID && booking.Status == "Waitlisted" {
+			waitlist = append(waitlist, booking)
+		}
+	}
+	sortByCreation(waitlist)
+	return waitlist
+}
+
+// FindWaitlistForTicketType returns the waitlist of one ticket type of a conference in joining order.
+func (r *inMemoryRepository) FindWaitlistForTicketType(ctx context.Context, conferenceID, ticketType string) []*Booking {
+	_, span := tracer.Start(ctx, "booking.Repository.FindWaitlistForTicketType", trace.WithAttributes(attribute.String("conference.id", conferenceID), attribute.String("ticket_type", ticketType)))
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var waitlist []*Booking
+	for _, booking := range r.bookings {
+		if booking.ConferenceID == conferenceID && booking.TicketType == ticketType && booking.Status == "Waitlisted" {
+			waitlist = append(waitlist, booking)
+		}
+	}
+	sortByCreation(waitlist)
+	return waitlist
+}
+
+// FindByConference returns every booking of a conference in creation order.
+func (r *inMemoryRepository) FindByConference(ctx context.Context, conferenceID string) []*Booking {
+	_, span := tracer.Start(ctx, "booking.Repository.FindByConference", trace.WithAttributes(attribute.String("conference.id", conferenceID)))
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var bookings []*Booking
+	for _, booking := range r.bookings {
+		if booking.ConferenceID == conferenceID {
+			bookings = append(bookings, booking)
+		}
+	}
+	sortByCreation(bookings)
+	return bookings
+}
+
+// FindByGroup returns the seats of a group booking in creation order.
+func (r *inMemoryRepository) FindByGroup(ctx context.Context, groupID string) []*Booking {
+	_, span := tracer.Start(ctx, "booking.Repository.FindByGroup", trace.WithAttributes(attribute.String("group.id", groupID)))
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var bookings []*Booking
+	for _, booking := range r.bookings {
+		if booking.GroupID == groupID {
+			bookings = append(bookings, booking)
+		}
+	}
+	sortByCreation(bookings)
+	return bookings
+}
+
 // New Method: FindActiveBooking
-func (r *inMemoryRepository) FindActiveBooking(userID, conferenceID string) (*Booking, error) {
+// An empty sessionID looks for a booking of the whole conference.
+func (r *inMemoryRepository) FindActiveBooking(ctx context.Context, userID, conferenceID, sessionID string) (*Booking, error) {
+	_, span := tracer.Start(ctx, "booking.Repository.FindActiveBooking", trace.WithAttributes(attribute.String("user.id", userID), attribute.String("conference.id", conferenceID)))
+	defer span.End()
+
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
 	for _, booking := range r.bookings {
-		if booking.UserID == userID && booking.ConferenceID == conferenceID && booking.Status != "Cancelled" && booking.Status != "Expired" {
+		if booking.UserID == userID && booking.ConferenceID == conferenceID && booking.SessionID == sessionID &&
+			booking.Status != "Cancelled" && booking.Status != "Canceled" && booking.Status != "Expired" {
 			return booking, nil
 		}
 	}
 	return nil, errors.ErrNotFound
 }
 
-// New Method: RemoveOverlappingWaitlists
-func (r *inMemoryRepository) RemoveOverlappingWaitlists(userID string, start, end time.Time) error {
+// RemoveOverlappingWaitlists cancels the user's waitlisted conference bookings that overlap the given
+// time window and returns them.
+func (r *inMemoryRepository) RemoveOverlappingWaitlists(ctx context.Context, userID string, start, end time.Time) []*Booking {
+	ctx, span := tracer.Start(ctx, "booking.Repository.RemoveOverlappingWaitlists", trace.WithAttributes(attribute.String("user.id", userID)))
+	defer span.End()
+
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
+	var removed []*Booking
 	for _, booking := range r.bookings {
-		if booking.UserID == userID && booking.Status == "Waitlisted" {
+		if booking.UserID == userID && booking.SessionID == "" && booking.Status == "Waitlisted" {
 			// Fetch conference details using its ID
-			conf, err := r.conferenceRepo.FindByName(booking.ConferenceID)
+			conf, err := r.conferenceRepo.FindByName(ctx, booking.ConferenceID)
 			if err != nil {
 				continue // Skip if conference not found
 			}
 
 			// Check for overlapping timeframes
-			if !(end.Before(conf.StartTime) || start.After(conf.EndTime)) {
+			if overlaps(start, end, conf.StartTime, conf.EndTime) {
 				booking.Status = "Cancelled"
 				r.bookings[booking.ID] = booking
+				removed = append(removed, booking)
 			}
 		}
 	}
-	return nil
+	return removed
 }
 
-func (r *inMemoryRepository) HasOverlappingConfirmedBookings(userID string, start, end time.Time) (bool, error) {
+// FindOverlappingBooking returns a booking of the user that holds a seat (confirmed, awaiting
+// payment or attended) at a time overlapping the given window, or nil when there is none. Conference
+// and session bookings are compared with each other, except that a session does not clash with a
+// booking of its own conference.
+func (r *inMemoryRepository) FindOverlappingBooking(ctx context.Context, userID, conferenceID, sessionID string, start, end time.Time) (*Booking, error) {
+	ctx, span := tracer.Start(ctx, "booking.Repository.FindOverlappingBooking", trace.WithAttributes(attribute.String("user.id", userID)))
+	defer span.End()
+
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
+	return r.findOverlapping(ctx, userID, conferenceID, sessionID, start, end), nil
+}
+
+// findOverlapping is FindOverlappingBooking for callers that hold the lock.
+func (r *inMemoryRepository) findOverlapping(ctx context.Context, userID, conferenceID, sessionID string, start, end time.Time) *Booking {
 	for _, booking := range r.bookings {
-		if booking.UserID == userID && booking.Status == "Confirmed" {
-			conf, err := r.conferenceRepo.FindByName(booking.ConferenceID)
+		if booking.UserID != userID || !holdsSeat(booking) {
+			continue
+		}
+		if booking.ConferenceID == conferenceID && (booking.SessionID == "") != (sessionID == "") {
+			continue
+		}
+
+		var otherStart, otherEnd time.Time
+		if booking.SessionID != "" {
+			session, err := r.conferenceRepo.FindSession(ctx, booking.SessionID)
 			if err != nil {
-				continue // Skip if conference not found
+				continue // Skip if session not found
 			}
-			// Check for overlapping times
-			if !(end.Before(conf.StartTime) || start.After(conf.EndTime)) {
-				return true, nil
+			otherStart, otherEnd = session.StartTime, session.EndTime
+		} else {
+			conf, err := r.conferenceRepo.FindByName(ctx, booking.ConferenceID)
+			if err != nil {
+				continue // Skip if conference not found
 			}
+			otherStart, otherEnd = conf.StartTime, conf.EndTime
+		}
+		if overlaps(start, end, otherStart, otherEnd) {
+			return booking
+		}
+	}
+	return nil
+}
+
+// Transfer moves a confirmed booking from one user to another in a single step. It fails with
+// ErrConflict when the booking changed holder or status meanwhile, or when the recipient already
+// holds an active booking for the same conference or session, and with an OverlapError when the
+// recipient holds a seat between start and end.
+func (r *inMemoryRepository) Transfer(ctx context.Context, bookingID, fromUserID, toUserID string, start, end, at time.Time) (*Booking, error) {
+	ctx, span := tracer.Start(ctx, "booking.Repository.Transfer", trace.WithAttributes(attribute.String("booking.id", bookingID), attribute.String("user.id", toUserID)))
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	booking, exists := r.bookings[bookingID]
+	if !exists {
+		return nil, errors.ErrNotFound
+	}
+	if booking.UserID != fromUserID || booking.Status != "Confirmed" {
+		return nil, errors.ErrConflict
+	}
+	for _, other := range r.bookings {
+		if other.UserID == toUserID && other.ConferenceID == booking.ConferenceID && other.SessionID == booking.SessionID &&
+			other.Status != "Cancelled" && other.Status != "Canceled" && other.Status != "Expired" {
+			return nil, errors.ErrConflict
 		}
 	}
-	return false, nil
+	if conflicting := r.findOverlapping(ctx, toUserID, booking.ConferenceID, booking.SessionID, start, end); conflicting != nil {
+		return nil, &OverlapError{Conflicting: conflicting}
+	}
+
+	booking.UserID = toUserID
+	booking.Transfers = append(booking.Transfers, Transfer{FromUserID: fromUserID, ToUserID: toUserID, TransferredAt: at})
+	return booking, nil
 }
 
-func (r *inMemoryRepository) GetAllBookings() []*Booking {
+// CheckIn marks a confirmed booking Attended. It fails with ErrConflict when the booking is no
+// longer confirmed, so that two scans of the same token cannot both succeed.
+func (r *inMemoryRepository) CheckIn(ctx context.Context, bookingID string, at time.Time) error {
+	_, span := tracer.Start(ctx, "booking.Repository.CheckIn", trace.WithAttributes(attribute.String("booking.id", bookingID)))
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	booking, exists := r.bookings[bookingID]
+	if !exists {
+		return errors.ErrNotFound
+	}
+	if booking.Status != "Confirmed" {
+		return errors.ErrConflict
+	}
+
+	booking.Status = "Attended"
+	booking.CheckedInAt = &at
+	return nil
+}
+
+func (r *inMemoryRepository) GetAllBookings(ctx context.Context) []*Booking {
+	_, span := tracer.Start(ctx, "booking.Repository.GetAllBookings")
+	defer span.End()
+
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
@@ -162,3 +389,362 @@ func (r *inMemoryRepository) GetAllBookings() []*Booking {
 	}
 	return allBookings
 }
+
+// List returns bookings filtered by status, conference, user and creation-time window.
+func (r *inMemoryRepository) List(ctx context.Context, q query.Query) (query.Page[*Booking], error) {
+	_, span := tracer.Start(ctx, "booking.Repository.List")
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var bookings []*Booking
+	for _, booking := range r.bookings {
+		if q.Status != "" && booking.Status != q.Status {
+			continue
+		}
+		if q.ConferenceID != "" && booking.ConferenceID != q.ConferenceID {
+			continue
+		}
+		if q.UserID != "" && booking.UserID != q.UserID {
+			continue
+		}
+		if !q.InRange(booking.CreatedAt) {
+			continue
+		}
+		bookings = append(bookings, booking)
+	}
+
+	return query.Apply(bookings, q, func(b *Booking) string { return b.ID }, map[string]query.Key[*Booking]{
+		"created_at":    func(b *Booking) string { return query.TimeKey(b.CreatedAt) },
+		"status":        func(b *Booking) string { return b.Status },
+		"conference_id": func(b *Booking) string { return b.ConferenceID },
+		"user_id":       func(b *Booking) string { return b.UserID },
+	})
+}
+
+func (r *inMemoryRepository) CreateOrder(ctx context.Context, order *Order) error {
+	_, span := tracer.Start(ctx, "booking.Repository.CreateOrder", trace.WithAttributes(attribute.String("order.id", order.ID)))
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.orders[order.ID] = order
+	return nil
+}
+
+func (r *inMemoryRepository) FindOrder(ctx context.Context, id string) (*Order, error) {
+	_, span := tracer.Start(ctx, "booking.Repository.FindOrder", trace.WithAttributes(attribute.String("order.id", id)))
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	order, exists := r.orders[id]
+	if !exists {
+		return nil, errors.ErrNotFound
+	}
+	return order, nil
+}
+
+func (r *inMemoryRepository) UpdateOrder(ctx context.Context, order *Order) error {
+	_, span := tracer.Start(ctx, "booking.Repository.UpdateOrder", trace.WithAttributes(attribute.String("order.id", order.ID)))
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.orders[order.ID]; !exists {
+		return errors.ErrNotFound
+	}
+	r.orders[order.ID] = order
+	return nil
+}
+
+// UpdateOrderStatus moves an order from one status to another in a single step. It fails with
+// ErrConflict when the order is no longer in the expected status, so that two payments of the
+// same order, or a payment and the order's expiry, cannot both go ahead.
+func (r *inMemoryRepository) UpdateOrderStatus(ctx context.Context, orderID, from, to string) error {
+	_, span := tracer.Start(ctx, "booking.Repository.UpdateOrderStatus", trace.WithAttributes(attribute.String("order.id", orderID), attribute.String("order.status", to)))
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	order, exists := r.orders[orderID]
+	if !exists {
+		return errors.ErrNotFound
+	}
+	if order.Status != from {
+		return errors.ErrConflict
+	}
+	order.Status = to
+	return nil
+}
+
+// ConfirmPendingPayment confirms those of the given bookings that are still PendingPayment in a
+// single step and returns the IDs it confirmed. Bookings cancelled meanwhile stay cancelled.
+func (r *inMemoryRepository) ConfirmPendingPayment(ctx context.Context, bookingIDs []string) map[string]bool {
+	_, span := tracer.Start(ctx, "booking.Repository.ConfirmPendingPayment")
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	confirmed := make(map[string]bool, len(bookingIDs))
+	for _, id := range bookingIDs {
+		if booking, exists := r.bookings[id]; exists && booking.Status == "PendingPayment" {
+			booking.Status = "Confirmed"
+			confirmed[id] = true
+		}
+	}
+	return confirmed
+}
+
+// FindExpiredOrders returns the pending orders whose payment deadline has passed.
+func (r *inMemoryRepository) FindExpiredOrders(ctx context.Context, now time.Time) []*Order {
+	_, span := tracer.Start(ctx, "booking.Repository.FindExpiredOrders")
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var orders []*Order
+	for _, order := range r.orders {
+		if order.Status == OrderPending && !order.ExpiresAt.After(now) {
+			orders = append(orders, order)
+		}
+	}
+	return orders
+}
+
+// FindLotteryEntries returns the lottery entries of a conference in entry order.
+func (r *inMemoryRepository) FindLotteryEntries(ctx context.Context, conferenceID string) []*Booking {
+	_, span := tracer.Start(ctx, "booking.Repository.FindLotteryEntries", trace.WithAttributes(attribute.String("conference.id", conferenceID)))
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var entries []*Booking
+	for _, booking := range r.bookings {
+		if booking.ConferenceID == conferenceID && booking.Status == "LotteryEntry" {
+			entries = append(entries, booking)
+		}
+	}
+	sortByCreation(entries)
+	return entries
+}
+
+func (r *inMemoryRepository) CreateDraw(ctx context.Context, draw *Draw) error {
+	_, span := tracer.Start(ctx, "booking.Repository.CreateDraw", trace.WithAttributes(attribute.String("conference.id", draw.ConferenceName)))
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.draws[draw.ConferenceName]; exists {
+		return errors.ErrConflict
+	}
+	r.draws[draw.ConferenceName] = draw
+	return nil
+}
+
+func (r *inMemoryRepository) FindDraw(ctx context.Context, conferenceID string) (*Draw, error) {
+	_, span := tracer.Start(ctx, "booking.Repository.FindDraw", trace.WithAttributes(attribute.String("conference.id", conferenceID)))
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	draw, exists := r.draws[conferenceID]
+	if !exists {
+		return nil, errors.ErrNotFound
+	}
+	return draw, nil
+}
+
+func (r *inMemoryRepository) CreateHold(ctx context.Context, hold *Hold) error {
+	_, span := tracer.Start(ctx, "booking.Repository.CreateHold", trace.WithAttributes(attribute.String("hold.id", hold.ID), attribute.String("conference.id", hold.ConferenceName)))
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.holds[hold.ID] = hold
+	return nil
+}
+
+func (r *inMemoryRepository) FindHold(ctx context.Context, id string) (*Hold, error) {
+	_, span := tracer.Start(ctx, "booking.Repository.FindHold", trace.WithAttributes(attribute.String("hold.id", id)))
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	hold, exists := r.holds[id]
+	if !exists {
+		return nil, errors.ErrNotFound
+	}
+	return hold, nil
+}
+
+// FindActiveHold returns the active hold of a user on a conference (or session), if any.
+func (r *inMemoryRepository) FindActiveHold(ctx context.Context, userID, conferenceID, sessionID string) (*Hold, error) {
+	_, span := tracer.Start(ctx, "booking.Repository.FindActiveHold", trace.WithAttributes(attribute.String("user.id", userID), attribute.String("conference.id", conferenceID)))
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, hold := range r.holds {
+		if hold.UserID == userID && hold.ConferenceName == conferenceID && hold.SessionID == sessionID && hold.Status == HoldActive {
+			return hold, nil
+		}
+	}
+	return nil, errors.ErrNotFound
+}
+
+// FindActiveHolds returns the active holds on a conference and its sessions.
+func (r *inMemoryRepository) FindActiveHolds(ctx context.Context, conferenceID string) []*Hold {
+	_, span := tracer.Start(ctx, "booking.Repository.FindActiveHolds", trace.WithAttributes(attribute.String("conference.id", conferenceID)))
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var holds []*Hold
+	for _, hold := range r.holds {
+		if hold.ConferenceName == conferenceID && hold.Status == HoldActive {
+			holds = append(holds, hold)
+		}
+	}
+	return holds
+}
+
+func (r *inMemoryRepository) UpdateHold(ctx context.Context, hold *Hold) error {
+	_, span := tracer.Start(ctx, "booking.Repository.UpdateHold", trace.WithAttributes(attribute.String("hold.id", hold.ID)))
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.holds[hold.ID]; !exists {
+		return errors.ErrNotFound
+	}
+	r.holds[hold.ID] = hold
+	return nil
+}
+
+// UpdateHoldStatus moves a hold from one status to another in a single step. It fails with
+// ErrConflict when the hold is no longer in the expected status, so that a hold is committed or
+// released once, never both.
+func (r *inMemoryRepository) UpdateHoldStatus(ctx context.Context, holdID, from, to string) error {
+	_, span := tracer.Start(ctx, "booking.Repository.UpdateHoldStatus", trace.WithAttributes(attribute.String("hold.id", holdID), attribute.String("hold.status", to)))
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	hold, exists := r.holds[holdID]
+	if !exists {
+		return errors.ErrNotFound
+	}
+	if hold.Status != from {
+		return errors.ErrConflict
+	}
+	hold.Status = to
+	return nil
+}
+
+// FindExpiredHolds returns the active holds whose time has run out.
+func (r *inMemoryRepository) FindExpiredHolds(ctx context.Context, now time.Time) []*Hold {
+	_, span := tracer.Start(ctx, "booking.Repository.FindExpiredHolds")
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var holds []*Hold
+	for _, hold := range r.holds {
+		if hold.Status == HoldActive && !hold.ExpiresAt.After(now) {
+			holds = append(holds, hold)
+		}
+	}
+	return holds
+}
+
+func sortByCreation(bookings []*Booking) {
+	sort.Slice(bookings, func(i, j int) bool {
+		if !bookings[i].CreatedAt.Equal(bookings[j].CreatedAt) {
+			return bookings[i].CreatedAt.Before(bookings[j].CreatedAt)
+		}
+		return bookings[i].ID < bookings[j].ID
+	})
+}
+
+// overlaps reports whether two time windows share any instant.
+// Back-to-back windows (one ends exactly when the other starts) do not overlap.
+func overlaps(aStart, aEnd, bStart, bEnd time.Time) bool {
+	return aStart.Before(bEnd) && bStart.Before(aEnd)
+}
+
+// CreateSeriesBooking stores a series booking. A user books a series at most once.
+func (r *inMemoryRepository) CreateSeriesBooking(ctx context.Context, seriesBooking *SeriesBooking) error {
+	_, span := tracer.Start(ctx, "booking.Repository.CreateSeriesBooking", trace.WithAttributes(attribute.String("series.id", seriesBooking.SeriesID), attribute.String("user.id", seriesBooking.UserID)))
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, existing := range r.seriesBookings {
+		if existing.ID == seriesBooking.ID || (existing.SeriesID == seriesBooking.SeriesID && existing.UserID == seriesBooking.UserID) {
+			return errors.ErrConflict
+		}
+	}
+	r.seriesBookings[seriesBooking.ID] = seriesBooking
+	return nil
+}
+
+func (r *inMemoryRepository) FindSeriesBooking(ctx context.Context, id string) (*SeriesBooking, error) {
+	_, span := tracer.Start(ctx, "booking.Repository.FindSeriesBooking", trace.WithAttributes(attribute.String("series_booking.id", id)))
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	seriesBooking, exists := r.seriesBookings[id]
+	if !exists {
+		return nil, errors.ErrNotFound
+	}
+	return seriesBooking, nil
+}
+
+func (r *inMemoryRepository) UpdateSeriesBooking(ctx context.Context, seriesBooking *SeriesBooking) error {
+	_, span := tracer.Start(ctx, "booking.Repository.UpdateSeriesBooking", trace.WithAttributes(attribute.String("series_booking.id", seriesBooking.ID)))
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.seriesBookings[seriesBooking.ID]; !exists {
+		return errors.ErrNotFound
+	}
+	r.seriesBookings[seriesBooking.ID] = seriesBooking
+	return nil
+}
+
+func (r *inMemoryRepository) ListSeriesBookings(ctx context.Context) []*SeriesBooking {
+	_, span := tracer.Start(ctx, "booking.Repository.ListSeriesBookings")
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	seriesBookings := make([]*SeriesBooking, 0, len(r.seriesBookings))
+	for _, seriesBooking := range r.seriesBookings {
+		seriesBookings = append(seriesBookings, seriesBooking)
+	}
+	return seriesBookings
+}
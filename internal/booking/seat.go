@@ -0,0 +1,122 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	apperrors "conference-booking/pkg/errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	ErrSeatTaken       = errors.New("seat is already taken")
+	ErrSeatUnavailable = errors.New("no matching seat available")
+)
+
+// seatPicker hands out the free seats of a conference's seat map. Seats held by bookings (or kept
+// for promoted ones) or active holds are taken; picked seats are taken for the rest of the picker's life.
+type seatPicker struct {
+	pool  *pool
+	taken map[string]bool
+}
+
+// seats returns a picker for a pool. Pools without seats (sessions, or conferences without a seat
+// map) get a picker that hands out none.
+func (s *service) seats(ctx context.Context, p *pool) *seatPicker {
+	picker := &seatPicker{pool: p, taken: make(map[string]bool)}
+	if p.session != nil || p.conf.SeatMap == nil {
+		return picker
+	}
+
+	for _, booking := range s.bookingRepo.FindByConference(ctx, p.conf.Name) {
+		if booking.SessionID == "" && booking.Seat != "" && keepsSeat(booking) {
+			picker.taken[booking.Seat] = true
+		}
+	}
+	for _, hold := range s.bookingRepo.FindActiveHolds(ctx, p.conf.Name) {
+		if hold.SessionID == "" && hold.Seat != "" {
+			picker.taken[hold.Seat] = true
+		}
+	}
+	return picker
+}
+
+// pick takes the requested seat, or the first free seat in row order. Accessible seats are given
+// to those who ask for one, and to others only when nothing else is left.
+func (sp *seatPicker) pick(requested string, accessible bool) (string, error) {
+	seatMap := sp.pool.conf.SeatMap
+	if sp.pool.session != nil || seatMap == nil {
+		if requested != "" || accessible {
+			return "", fmt.Errorf("%w: no seat map to choose from", apperrors.ErrInvalidInput)
+		}
+		return "", nil
+	}
+
+	if requested != "" {
+		if seatMap.Find(requested) == nil {
+			return "", fmt.Errorf("%w: unknown seat %s", apperrors.ErrInvalidInput, requested)
+		}
+		if sp.taken[requested] {
+			return "", ErrSeatTaken
+		}
+		sp.taken[requested] = true
+		return requested, nil
+	}
+
+	fallback := ""
+	for _, row := range seatMap.Rows {
+		for _, seat := range row.Seats {
+			if sp.taken[seat.Label] {
+				continue
+			}
+			if seat.Accessible == accessible {
+				sp.taken[seat.Label] = true
+				return seat.Label, nil
+			}
+			if fallback == "" && !accessible {
+				fallback = seat.Label
+			}
+		}
+	}
+	if fallback == "" {
+		return "", ErrSeatUnavailable
+	}
+	sp.taken[fallback] = true
+	return fallback, nil
+}
+
+// GetSeatAvailability lists the seat map of a conference with each seat's availability. Drafts are
+// found by their owner only.
+func (s *service) GetSeatAvailability(ctx context.Context, conferenceName, requesterID string) (*SeatAvailability, error) {
+	ctx, span := tracer.Start(ctx, "booking.Service.GetSeatAvailability", trace.WithAttributes(attribute.String("conference.id", conferenceName)))
+	defer span.End()
+
+	conf, err := s.confRepo.FindByName(ctx, conferenceName)
+	if err != nil {
+		return nil, err
+	}
+	if conf.SeatMap == nil || !conf.VisibleTo(requesterID) {
+		return nil, apperrors.ErrNotFound
+	}
+	s.releaseExpiredHolds(ctx, conf.Name, time.Now())
+
+	picker := s.seats(ctx, &pool{conf: conf})
+	availability := &SeatAvailability{Conference: conf.Name, Rows: []*SeatRowAvailability{}}
+	for _, row := range conf.SeatMap.Rows {
+		rowAvailability := &SeatRowAvailability{Label: row.Label, Seats: []*SeatState{}}
+		for _, seat := range row.Seats {
+			free := !picker.taken[seat.Label]
+			rowAvailability.Seats = append(rowAvailability.Seats, &SeatState{Label: seat.Label, Accessible: seat.Accessible, Available: free})
+			availability.Total++
+			if free {
+				availability.Available++
+			}
+		}
+		availability.Rows = append(availability.Rows, rowAvailability)
+	}
+	return availability, nil
+}
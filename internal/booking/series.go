@@ -0,0 +1,168 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"time"
+
+	"conference-booking/internal/conference"
+	apperrors "conference-booking/pkg/errors"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// BookSeries books a user into every future occurrence of a series, each as a regular conference
+// booking (confirmed or waitlisted). The series is booked whole or not at all: if one occurrence
+// cannot be booked, the occurrences booked so far are canceled again. Occurrences generated later
+// are booked by the cleanup worker.
+func (s *service) BookSeries(ctx context.Context, req BookSeriesRequest) (*SeriesBooking, error) {
+	ctx, span := tracer.Start(ctx, "booking.Service.BookSeries", trace.WithAttributes(attribute.String("series.id", req.SeriesID), attribute.String("user.id", req.UserID)))
+	defer span.End()
+
+	if _, err := s.confRepo.FindSeries(ctx, req.SeriesID); err != nil {
+		return nil, err
+	}
+
+	seriesBooking := &SeriesBooking{
+		ID:           uuid.New().String(),
+		SeriesID:     req.SeriesID,
+		UserID:       req.UserID,
+		AllowOverlap: req.AllowOverlap,
+		Occurrences:  make(map[string]string),
+		CreatedAt:    time.Now().UTC(),
+	}
+	err := s.forEachNewOccurrence(ctx, seriesBooking, time.Now(), func(conf *conference.Conference) error {
+		return s.bookOccurrence(ctx, seriesBooking, conf)
+	})
+	if err == nil {
+		err = s.bookingRepo.CreateSeriesBooking(ctx, seriesBooking)
+	}
+	if err != nil {
+		for _, bookingID := range seriesBooking.Occurrences {
+			_ = s.CancelBooking(ctx, bookingID)
+		}
+		return nil, err
+	}
+	return seriesBooking, nil
+}
+
+func (s *service) GetSeriesBooking(ctx context.Context, id string) (*SeriesBooking, error) {
+	ctx, span := tracer.Start(ctx, "booking.Service.GetSeriesBooking", trace.WithAttributes(attribute.String("series_booking.id", id)))
+	defer span.End()
+
+	return s.bookingRepo.FindSeriesBooking(ctx, id)
+}
+
+// CancelSeriesBooking stops a series booking: no further occurrences are booked, and the bookings of
+// occurrences that have not started are cancelled. Only the booked user may cancel. An occurrence
+// that fails to cancel does not stop the others; cancelling again retries it.
+func (s *service) CancelSeriesBooking(ctx context.Context, id, requesterID string) (*SeriesBooking, error) {
+	ctx, span := tracer.Start(ctx, "booking.Service.CancelSeriesBooking", trace.WithAttributes(attribute.String("series_booking.id", id)))
+	defer span.End()
+
+	existing, err := s.bookingRepo.FindSeriesBooking(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if existing.UserID != requesterID {
+		return nil, apperrors.ErrForbidden
+	}
+
+	// Stop booking new occurrences first
+	seriesBooking := existing
+	if existing.CancelledAt == nil {
+		copied := *existing
+		now := time.Now().UTC()
+		copied.CancelledAt = &now
+		if err := s.bookingRepo.UpdateSeriesBooking(ctx, &copied); err != nil {
+			return nil, err
+		}
+		seriesBooking = &copied
+	}
+
+	now := time.Now()
+	var failed []error
+	for _, bookingID := range seriesBooking.Occurrences {
+		booking, err := s.bookingRepo.FindByID(ctx, bookingID)
+		if err != nil {
+			failed = append(failed, err)
+			continue
+		}
+		switch booking.Status {
+		case "Canceled", "Cancelled", "Expired", "NoShow", "Attended":
+			continue
+		}
+		conf, err := s.confRepo.FindByName(ctx, booking.ConferenceID)
+		if err != nil {
+			failed = append(failed, err)
+			continue
+		}
+		if !now.Before(conf.StartTime) {
+			continue
+		}
+		if err := s.CancelBooking(ctx, bookingID); err != nil {
+			failed = append(failed, fmt.Errorf("occurrence %s: %w", conf.Name, err))
+		}
+	}
+	if len(failed) > 0 {
+		return nil, fmt.Errorf("series booking is cancelled but %d occurrences are not, cancel again to retry: %w", len(failed), errors.Join(failed...))
+	}
+	return seriesBooking, nil
+}
+
+// bookNewOccurrences extends series bookings to occurrences generated since they were made.
+// Occurrences that cannot be booked (e.g. because of an overlap) are retried on the next run.
+func (s *service) bookNewOccurrences(ctx context.Context, now time.Time) {
+	for _, existing := range s.bookingRepo.ListSeriesBookings(ctx) {
+		if existing.CancelledAt != nil {
+			continue
+		}
+		// Work on a copy so readers never see the occurrence map change under them
+		seriesBooking := *existing
+		seriesBooking.Occurrences = maps.Clone(existing.Occurrences)
+		_ = s.forEachNewOccurrence(ctx, &seriesBooking, now, func(conf *conference.Conference) error {
+			_ = s.bookOccurrence(ctx, &seriesBooking, conf)
+			return nil
+		})
+		if len(seriesBooking.Occurrences) > len(existing.Occurrences) {
+			_ = s.bookingRepo.UpdateSeriesBooking(ctx, &seriesBooking)
+		}
+	}
+}
+
+// forEachNewOccurrence calls fn for every occurrence of the series that starts after now and is not
+// part of the series booking yet, stopping at the first error.
+func (s *service) forEachNewOccurrence(ctx context.Context, seriesBooking *SeriesBooking, now time.Time, fn func(*conference.Conference) error) error {
+	for _, conf := range s.confRepo.FindBySeries(ctx, seriesBooking.SeriesID) {
+		if _, booked := seriesBooking.Occurrences[conf.Name]; booked || !conf.StartTime.After(now) {
+			continue
+		}
+		if err := fn(conf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *service) bookOccurrence(ctx context.Context, seriesBooking *SeriesBooking, conf *conference.Conference) error {
+	bookingID, err := s.BookConference(ctx, BookConferenceRequest{
+		ConferenceName: conf.Name,
+		UserID:         seriesBooking.UserID,
+		AllowOverlap:   seriesBooking.AllowOverlap,
+	})
+	if err != nil {
+		return fmt.Errorf("occurrence %s: %w", conf.Name, err)
+	}
+	seriesBooking.Occurrences[conf.Name] = bookingID
+
+	booking, err := s.bookingRepo.FindByID(ctx, bookingID)
+	if err != nil {
+		return err
+	}
+	booking.SeriesBookingID = seriesBooking.ID
+	return s.bookingRepo.Update(ctx, booking)
+}
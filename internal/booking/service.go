@@ -1,138 +1,326 @@
 package booking
 
 import (
+	"context"
+	"crypto/subtle"
 	"errors"
+	"fmt"
 	"time"
 
 	"conference-booking/internal/conference"
+	"conference-booking/internal/notification"
+	"conference-booking/internal/payment"
 	"conference-booking/internal/user"
+	"conference-booking/pkg/checkin"
+	apperrors "conference-booking/pkg/errors"
+	"conference-booking/pkg/query"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("conference-booking/internal/booking")
+
 var (
 	ErrInvalidAction   = errors.New("action not allowed")
 	ErrSlotUnavailable = errors.New("no slots available")
 	ErrBookingConflict = errors.New("user already has a confirmed booking")
 	ErrWaitlistExpired = errors.New("waitlist confirmation expired")
+
+	ErrTicketSaleClosed   = errors.New("ticket type is not on sale")
+	ErrCancellationClosed = errors.New("bookings cannot be cancelled after the start")
+	ErrInviteRequired     = errors.New("conference is invite-only")
+	ErrInvalidCode        = errors.New("invalid or expired code")
 )
 
+// OverlapError is returned when a booking would overlap another confirmed booking of the same user.
+// It matches ErrBookingConflict with errors.Is.
+type OverlapError struct {
+	Conflicting *Booking
+}
+
+func (e *OverlapError) Error() string {
+	return ErrBookingConflict.Error() + " at the same time with ID: " + e.Conflicting.ID
+}
+
+func (e *OverlapError) Unwrap() error {
+	return ErrBookingConflict
+}
+
 type Service interface {
-	BookConference(req BookConferenceRequest) (string, error)
-	ConfirmWaitlistBooking(bookingID string) error
-	CancelBooking(bookingID string) error
-	GetBookingStatus(bookingID string) (*BookingStatus, error)
+	BookConference(ctx context.Context, req BookConferenceRequest) (string, error)
+	ConfirmWaitlistBooking(ctx context.Context, req ConfirmWaitlistRequest) error
+	CancelBooking(ctx context.Context, bookingID string) error
+	GetBookingStatus(ctx context.Context, bookingID string) (*BookingStatus, error)
+	GetWaitlistPosition(ctx context.Context, bookingID string) (*WaitlistPosition, error)
+	HoldSeat(ctx context.Context, req HoldSeatRequest) (*Hold, error)
+	CommitHold(ctx context.Context, holdID string, req CommitHoldRequest, requesterID string) (*Booking, error)
+	ListBookings(ctx context.Context, q query.Query, requesterID string) (query.Page[*Booking], error)
+	GetRoster(ctx context.Context, conferenceName, requesterID string) (*Roster, error)
+	GetUserCalendar(ctx context.Context, userID, token string) ([]*CalendarEntry, error)
+	BookGroup(ctx context.Context, req BookGroupRequest) (*GroupBooking, error)
+	GetGroup(ctx context.Context, groupID string) (*GroupBooking, error)
+	AssignSeat(ctx context.Context, groupID string, req AssignSeatRequest, requesterID string) (*Booking, error)
+	CancelGroup(ctx context.Context, groupID, requesterID string) error
+	TransferBooking(ctx context.Context, bookingID string, req TransferBookingRequest, requesterID string) (*Booking, error)
+	GetCheckInToken(ctx context.Context, bookingID, requesterID string) (string, error)
+	CheckIn(ctx context.Context, req CheckInRequest, requesterID string) (*Booking, error)
+	GetAttendance(ctx context.Context, conferenceName, requesterID string) (*Attendance, error)
+	DrawLottery(ctx context.Context, conferenceName string, req DrawRequest, requesterID string) (*Draw, error)
+	GetDraw(ctx context.Context, conferenceName string) (*Draw, error)
+	GetSeatAvailability(ctx context.Context, conferenceName, requesterID string) (*SeatAvailability, error)
+	GetOrder(ctx context.Context, orderID, requesterID string) (*Order, error)
+	PayOrder(ctx context.Context, orderID string, req PayOrderRequest, requesterID string) (*Order, error)
+	BookSeries(ctx context.Context, req BookSeriesRequest) (*SeriesBooking, error)
+	GetSeriesBooking(ctx context.Context, id string) (*SeriesBooking, error)
+	CancelSeriesBooking(ctx context.Context, id, requesterID string) (*SeriesBooking, error)
+	CancelConference(ctx context.Context, conferenceName, requesterID string) (*conference.Conference, error)
 	StartBookingCleanup(interval time.Duration)
 }
 
+// Config holds the deployment settings of the booking service. Zero durations fall back to
+// those of DefaultConfig.
+type Config struct {
+	NoShows     NoShowRules
+	PaymentHold time.Duration // how long a PendingPayment booking keeps its seat before the order expires
+	// CheckInOpensBefore is how long before the start of a conference (or session) check-in opens.
+	// It closes when the event ends.
+	CheckInOpensBefore time.Duration
+	HoldTTL            time.Duration // how long a seat hold keeps its seat
+}
+
+// DefaultConfig is used unless the deployment configures its own.
+var DefaultConfig = Config{
+	PaymentHold:        15 * time.Minute,
+	CheckInOpensBefore: time.Hour,
+	HoldTTL:            5 * time.Minute,
+}
+
+func (c Config) withDefaults() Config {
+	if c.PaymentHold <= 0 {
+		c.PaymentHold = DefaultConfig.PaymentHold
+	}
+	if c.CheckInOpensBefore <= 0 {
+		c.CheckInOpensBefore = DefaultConfig.CheckInOpensBefore
+	}
+	if c.HoldTTL <= 0 {
+		c.HoldTTL = DefaultConfig.HoldTTL
+	}
+	return c
+}
+
 type service struct {
 	confRepo    conference.Repository
 	userRepo    user.Repository
 	bookingRepo Repository
+	payments    payment.Provider
+	notifier    notification.Notifier
+	signer      *checkin.Signer
+	cfg         Config
 }
 
-func NewService(confRepo conference.Repository, userRepo user.Repository, bookingRepo Repository) Service {
-	return &service{confRepo: confRepo, userRepo: userRepo, bookingRepo: bookingRepo}
+func NewService(confRepo conference.Repository, userRepo user.Repository, bookingRepo Repository, payments payment.Provider, notifier notification.Notifier, signer *checkin.Signer, cfg Config) Service {
+	return &service{confRepo: confRepo, userRepo: userRepo, bookingRepo: bookingRepo, payments: payments, notifier: notifier, signer: signer, cfg: cfg.withDefaults()}
 }
 
-func (s *service) BookConference(req BookConferenceRequest) (string, error) {
-	// Find the conference
-	conf, err := s.confRepo.FindByName(req.ConferenceName)
+func (s *service) BookConference(ctx context.Context, req BookConferenceRequest) (string, error) {
+	ctx, span := tracer.Start(ctx, "booking.Service.BookConference", trace.WithAttributes(attribute.String("conference.id", req.ConferenceName), attribute.String("user.id", req.UserID)))
+	defer span.End()
+
+	// Find the conference and the pool of seats (session, ticket type or whole conference)
+	p, err := s.resolvePool(ctx, req.ConferenceName, req.SessionID, req.TicketType, req.Code)
 	if err != nil {
 		return "", err
 	}
+	conf := p.conf
+
+	// Holders of a code with reserved seats book from the reserve while it lasts
+	if p.code != nil && p.code.AvailableSlots > 0 {
+		p.reserved = true
+	}
 
 	// Find the user
-	_, err = s.userRepo.FindByID(req.UserID)
+	u, err := s.userRepo.FindByID(ctx, req.UserID)
 	if err != nil {
 		return "", err
 	}
+	if err := s.checkNoShows(u); err != nil {
+		return "", err
+	}
 
-	// Check if the user already has an active booking for this conference
-	existingBooking, err := s.bookingRepo.FindActiveBooking(req.UserID, conf.Name)
+	// Check if the user already has an active booking for this conference (or session)
+	existingBooking, err := s.bookingRepo.FindActiveBooking(ctx, req.UserID, conf.Name, req.SessionID)
 	if err == nil {
 		return "", errors.New("user already has an active booking with ID: " + existingBooking.ID)
 	}
 
 	// Create a booking
 	bookingID := uuid.New().String()
-	if conf.AvailableSlots > 0 {
-		// Create a confirmed booking
+
+	// Until a lottery is drawn, conference bookings are entries; sessions and reserved seats are booked as usual
+	if conf.Lottery.Pending() && req.SessionID == "" && !p.reserved {
+		if err := s.enterLottery(ctx, p, req, bookingID); err != nil {
+			return "", err
+		}
+		return bookingID, nil
+	}
+
+	if p.available() > 0 {
+		// A user cannot hold two concurrent confirmed bookings unless they ask to
+		if !req.AllowOverlap {
+			if err := s.checkOverlap(ctx, req.UserID, p); err != nil {
+				return "", err
+			}
+		}
+		seat, err := s.seats(ctx, p).pick(req.Seat, req.Accessible)
+		if err != nil {
+			return "", err
+		}
+		if err := s.redeemCode(ctx, p, 1); err != nil {
+			return "", err
+		}
+
+		// Create a confirmed booking, or one holding its seat until paid for priced tickets
 		booking := &Booking{
 			ID:           bookingID,
 			UserID:       req.UserID,
 			ConferenceID: conf.Name,
+			SessionID:    req.SessionID,
+			TicketType:   req.TicketType,
+			Code:         req.Code,
+			ReservedSeat: p.reserved,
+			Seat:         seat,
+			Accessible:   req.Accessible,
 			Status:       "Confirmed",
+			CreatedAt:    time.Now().UTC(),
+		}
+		if err := s.holdForPayment(ctx, p, req.UserID, booking); err != nil {
+			return "", err
 		}
-		if err := s.bookingRepo.Create(booking); err != nil {
+		if err := s.bookingRepo.Create(ctx, booking); err != nil {
 			return "", err
 		}
 
 		// Reduce available slots
-		conf.AvailableSlots--
-		if err := s.confRepo.Update(conf); err != nil {
+		if err := s.adjustSlots(ctx, p, -1); err != nil {
 			return "", err
 		}
 		return bookingID, nil
 	}
 
 	// Add to waitlist
+	if err := s.redeemCode(ctx, p, 1); err != nil {
+		return "", err
+	}
 	waitlistUntil := time.Now().Add(1 * time.Hour)
 	booking := &Booking{
 		ID:            bookingID,
 		UserID:        req.UserID,
 		ConferenceID:  conf.Name,
+		SessionID:     req.SessionID,
+		TicketType:    req.TicketType,
+		Code:          req.Code,
+		Accessible:    req.Accessible,
 		Status:        "Waitlisted",
 		WaitlistUntil: &waitlistUntil,
+		CreatedAt:     time.Now().UTC(),
 	}
-	if err := s.bookingRepo.Create(booking); err != nil {
+	if err := s.bookingRepo.Create(ctx, booking); err != nil {
 		return "", err
 	}
 	return bookingID, nil
 }
 
-func (s *service) ConfirmWaitlistBooking(bookingID string) error {
+func (s *service) ConfirmWaitlistBooking(ctx context.Context, req ConfirmWaitlistRequest) error {
+	ctx, span := tracer.Start(ctx, "booking.Service.ConfirmWaitlistBooking", trace.WithAttributes(attribute.String("booking.id", req.BookingID)))
+	defer span.End()
+
 	// Find the booking
-	booking, err := s.bookingRepo.FindByID(bookingID)
+	booking, err := s.bookingRepo.FindByID(ctx, req.BookingID)
 	if err != nil {
 		return err
 	}
 
-	// Validate waitlist status and expiration
-	if booking.Status != "Waitlisted" || booking.WaitlistUntil.Before(time.Now()) {
+	// Validate waitlist status and expiration. A booking promoted from the waitlist
+	// (PendingConfirmation) already has a seat kept for it.
+	promoted := booking.Status == "PendingConfirmation"
+	if (booking.Status != "Waitlisted" && !promoted) || booking.WaitlistUntil.Before(time.Now()) {
 		return ErrWaitlistExpired
 	}
 
-	// Find the conference
-	conf, err := s.confRepo.FindByName(booking.ConferenceID)
+	// Users blocked by the no-show policy cannot confirm either
+	if u, err := s.userRepo.FindByID(ctx, booking.UserID); err == nil {
+		if err := s.checkNoShows(u); err != nil {
+			return err
+		}
+	}
+
+	// Find the conference and the pool of seats
+	p, err := s.findPool(ctx, booking)
 	if err != nil {
 		return err
 	}
 
 	// Check for available slots
-	if conf.AvailableSlots <= 0 {
+	if !promoted && p.available() <= 0 {
 		return ErrSlotUnavailable
 	}
 
-	// Confirm the booking
+	// A user cannot hold two concurrent confirmed bookings unless they ask to
+	// (unassigned group seats are checked when AssignSeat names their attendee)
+	if !req.AllowOverlap && booking.UserID != "" {
+		if err := s.checkOverlap(ctx, booking.UserID, p); err != nil {
+			return err
+		}
+	}
+
+	// Give the booking a free seat of the seat map, if any, unless one was kept for it
+	if booking.Seat == "" {
+		if booking.Seat, err = s.seats(ctx, p).pick("", booking.Accessible); err != nil {
+			return err
+		}
+	}
+
+	// Confirm the booking, or hold the seat until paid for priced tickets
 	booking.Status = "Confirmed"
-	if err := s.bookingRepo.Update(booking); err != nil {
+	payerID := booking.UserID
+	if booking.BookerID != "" {
+		payerID = booking.BookerID
+	}
+	if err := s.holdForPayment(ctx, p, payerID, booking); err != nil {
 		return err
 	}
-
-	// Reduce available slots
-	conf.AvailableSlots--
-	if err := s.confRepo.Update(conf); err != nil {
+	if err := s.bookingRepo.Update(ctx, booking); err != nil {
 		return err
 	}
 
+	// Reduce available slots (the seat of a promoted booking never went back to the pool)
+	if !promoted {
+		if err := s.adjustSlots(ctx, p, -1); err != nil {
+			return err
+		}
+	}
+	if p.session != nil || booking.UserID == "" {
+		return nil
+	}
+
 	// Remove user from overlapping waitlists
-	return s.bookingRepo.RemoveOverlappingWaitlists(booking.UserID, conf.StartTime, conf.EndTime)
+	for _, removed := range s.bookingRepo.RemoveOverlappingWaitlists(ctx, booking.UserID, p.conf.StartTime, p.conf.EndTime) {
+		if err := s.returnCodeUse(ctx, removed); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func (s *service) CancelBooking(bookingID string) error {
+func (s *service) CancelBooking(ctx context.Context, bookingID string) error {
+	ctx, span := tracer.Start(ctx, "booking.Service.CancelBooking", trace.WithAttributes(attribute.String("booking.id", bookingID)))
+	defer span.End()
+
 	// Find the booking
-	booking, err := s.bookingRepo.FindByID(bookingID)
+	booking, err := s.bookingRepo.FindByID(ctx, bookingID)
 	if err != nil {
 		return err
 	}
@@ -142,58 +330,346 @@ func (s *service) CancelBooking(bookingID string) error {
 		return errors.New("booking already canceled")
 	}
 
-	// Find the conference
-	conf, err := s.confRepo.FindByName(booking.ConferenceID)
+	// Find the conference and the pool of seats
+	p, err := s.findPool(ctx, booking)
 	if err != nil {
 		return err
 	}
 
-	// Cancel the booking
+	// Cancellation closes when the conference (or session) starts
+	now := time.Now()
+	if !now.Before(p.start()) {
+		return ErrCancellationClosed
+	}
+
+	// Refund paid seats as the conference's cancellation policy allows
+	if (booking.Status == "Confirmed" || booking.Status == "Attended") && booking.OrderID != "" {
+		if booking.Refund, err = s.refund(ctx, p, booking, now); err != nil {
+			return err
+		}
+	}
+
+	return s.cancel(ctx, p, booking)
+}
+
+// cancel releases a booking without applying the cancellation policy. A seat it held, or had
+// kept for it after a promotion, is offered to the first user on the waitlist of the same pool.
+func (s *service) cancel(ctx context.Context, p *pool, booking *Booking) error {
+	wasConfirmed := keepsSeat(booking)
+	wasPendingPayment := booking.Status == "PendingPayment"
+	wasWaiting := waitsForSeat(booking)
 	booking.Status = "Canceled"
-	if err := s.bookingRepo.Update(booking); err != nil {
+	if err := s.bookingRepo.Update(ctx, booking); err != nil {
 		return err
 	}
+	if wasWaiting {
+		if err := s.returnCodeUse(ctx, booking); err != nil {
+			return err
+		}
+	}
+	if wasPendingPayment {
+		if err := s.closeOrderIfEmpty(ctx, booking.OrderID); err != nil {
+			return err
+		}
+	}
 
 	// Handle slot reassignment for confirmed bookings
-	if booking.Status == "Confirmed" {
-		// Increase available slots
-		conf.AvailableSlots++
-
-		// Assign slot to the first waitlisted user
-		waitlist := s.bookingRepo.FindWaitlistForConference(conf.Name)
-		if len(waitlist) > 0 {
-			firstWaitlisted := waitlist[0]
-			firstWaitlisted.Status = "PendingConfirmation"
-			until := time.Now().Add(1 * time.Hour)
-			firstWaitlisted.WaitlistUntil = &until
-			if err := s.bookingRepo.Update(firstWaitlisted); err != nil {
-				return err
-			}
-		}
+	if wasConfirmed {
+		return s.releaseSeat(ctx, p, booking.Seat)
+	}
+
+	return nil
+}
 
-		// Update the conference
-		if err := s.confRepo.Update(conf); err != nil {
+// releaseSeat offers a freed seat (labelled seat on a seat map) to the first user on the pool's
+// waitlist, keeping it for them until they confirm or their confirmation window runs out.
+// Without a waitlist, or once the conference is cancelled, the seat goes back to the pool.
+func (s *service) releaseSeat(ctx context.Context, p *pool, seat string) error {
+	if p.conf.Status == conference.StatusCancelled {
+		return s.adjustSlots(ctx, p, 1)
+	}
+
+	// Assign slot to the first waitlisted user of the same pool
+	waitlist := s.waitlist(ctx, p)
+	if len(waitlist) > 0 {
+		firstWaitlisted := waitlist[0]
+		firstWaitlisted.Status = "PendingConfirmation"
+		firstWaitlisted.Seat = seat
+		until := time.Now().Add(1 * time.Hour)
+		firstWaitlisted.WaitlistUntil = &until
+		if err := s.bookingRepo.Update(ctx, firstWaitlisted); err != nil {
 			return err
 		}
+
+		recipient := firstWaitlisted.UserID
+		if recipient == "" {
+			recipient = firstWaitlisted.BookerID
+		}
+		s.notify(ctx, recipient, "Seat available", "A seat for "+firstWaitlisted.ConferenceID+" is kept for booking "+firstWaitlisted.ID+
+			" until "+until.UTC().Format(time.RFC3339)+". Confirm it to take it.")
+		return nil
+	}
+
+	// Increase available slots
+	return s.adjustSlots(ctx, p, 1)
+}
+
+// BookGroup reserves req.Seats seats for the booker. As many seats as are available are confirmed
+// and the remainder is waitlisted. Seats start without an attendee; see AssignSeat.
+func (s *service) BookGroup(ctx context.Context, req BookGroupRequest) (*GroupBooking, error) {
+	ctx, span := tracer.Start(ctx, "booking.Service.BookGroup", trace.WithAttributes(attribute.String("conference.id", req.ConferenceName), attribute.Int("booking.seats", req.Seats)))
+	defer span.End()
+
+	if req.Seats <= 0 {
+		return nil, apperrors.ErrInvalidInput
+	}
+
+	// Find the conference and the pool of seats
+	// (group seats never draw from the reserved seats of a code)
+	p, err := s.resolvePool(ctx, req.ConferenceName, req.SessionID, req.TicketType, req.Code)
+	if err != nil {
+		return nil, err
+	}
+	if p.conf.Lottery.Pending() && req.SessionID == "" {
+		return nil, ErrLotteryPending
+	}
+
+	// A group can at most fill the whole pool
+	if req.Seats > p.capacity() {
+		return nil, fmt.Errorf("%w: seats exceed the capacity of %d", apperrors.ErrInvalidInput, p.capacity())
+	}
+
+	// Find the booker
+	booker, err := s.userRepo.FindByID(ctx, req.BookerID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkNoShows(booker); err != nil {
+		return nil, err
+	}
+
+	// Every seat counts as one use of the code
+	if err := s.redeemCode(ctx, p, req.Seats); err != nil {
+		return nil, err
+	}
+
+	confirmed := max(min(req.Seats, p.available()), 0)
+
+	groupID := uuid.New().String()
+	now := time.Now().UTC()
+	waitlistUntil := now.Add(1 * time.Hour)
+	seats := make([]*Booking, req.Seats)
+	picker := s.seats(ctx, p)
+	for i := range seats {
+		seat := &Booking{
+			ID:           uuid.New().String(),
+			ConferenceID: p.conf.Name,
+			SessionID:    req.SessionID,
+			TicketType:   req.TicketType,
+			Code:         req.Code,
+			GroupID:      groupID,
+			BookerID:     req.BookerID,
+			Status:       "Confirmed",
+			CreatedAt:    now,
+		}
+		if i >= confirmed {
+			seat.Status = "Waitlisted"
+			seat.WaitlistUntil = &waitlistUntil
+		} else if seat.Seat, err = picker.pick("", false); err != nil {
+			return nil, err
+		}
+		seats[i] = seat
+	}
+
+	// Confirmed seats of priced tickets are paid for by the booker in one order
+	if err := s.holdForPayment(ctx, p, req.BookerID, seats[:confirmed]...); err != nil {
+		return nil, err
+	}
+	for _, seat := range seats {
+		if err := s.bookingRepo.Create(ctx, seat); err != nil {
+			return nil, err
+		}
+	}
+
+	// Reduce available slots
+	if confirmed > 0 {
+		if err := s.adjustSlots(ctx, p, -confirmed); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.GetGroup(ctx, groupID)
+}
+
+func (s *service) GetGroup(ctx context.Context, groupID string) (*GroupBooking, error) {
+	ctx, span := tracer.Start(ctx, "booking.Service.GetGroup", trace.WithAttributes(attribute.String("group.id", groupID)))
+	defer span.End()
+
+	seats := s.bookingRepo.FindByGroup(ctx, groupID)
+	if len(seats) == 0 {
+		return nil, apperrors.ErrNotFound
 	}
 
+	group := &GroupBooking{
+		ID:           groupID,
+		ConferenceID: seats[0].ConferenceID,
+		SessionID:    seats[0].SessionID,
+		BookerID:     seats[0].BookerID,
+		Seats:        seats,
+	}
+	for _, seat := range seats {
+		switch seat.Status {
+		case "Confirmed", "Attended":
+			group.Confirmed++
+		case "PendingPayment":
+			group.PendingPayment++
+		case "Waitlisted", "PendingConfirmation":
+			group.Waitlisted++
+		}
+	}
+	return group, nil
+}
+
+// AssignSeat names the attendee of one seat of a group. Only the booker may assign seats,
+// and the attendee must not already hold a booking for the same conference or session.
+func (s *service) AssignSeat(ctx context.Context, groupID string, req AssignSeatRequest, requesterID string) (*Booking, error) {
+	ctx, span := tracer.Start(ctx, "booking.Service.AssignSeat", trace.WithAttributes(attribute.String("group.id", groupID), attribute.String("booking.id", req.BookingID)))
+	defer span.End()
+
+	seat, err := s.bookingRepo.FindByID(ctx, req.BookingID)
+	if err != nil {
+		return nil, err
+	}
+	if seat.GroupID == "" || seat.GroupID != groupID {
+		return nil, apperrors.ErrNotFound
+	}
+	if seat.BookerID != requesterID {
+		return nil, apperrors.ErrForbidden
+	}
+	if seat.Status == "Canceled" || seat.Status == "Cancelled" {
+		return nil, ErrInvalidAction
+	}
+
+	// Find the attendee
+	if _, err := s.userRepo.FindByID(ctx, req.UserID); err != nil {
+		return nil, err
+	}
+
+	// Check the attendee has no other active booking for this conference (or session)
+	if existing, err := s.bookingRepo.FindActiveBooking(ctx, req.UserID, seat.ConferenceID, seat.SessionID); err == nil && existing.ID != seat.ID {
+		return nil, errors.New("user already has an active booking with ID: " + existing.ID)
+	}
+
+	// A user cannot hold two concurrent confirmed bookings unless they ask to
+	if holdsSeat(seat) && !req.AllowOverlap {
+		p, err := s.findPool(ctx, seat)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.checkOverlap(ctx, req.UserID, p); err != nil {
+			return nil, err
+		}
+	}
+
+	seat.UserID = req.UserID
+	if err := s.bookingRepo.Update(ctx, seat); err != nil {
+		return nil, err
+	}
+	return seat, nil
+}
+
+// CancelGroup cancels every seat of a group. Waitlisted seats go first so that the confirmed
+// seats released afterwards are offered to other users on the waitlist.
+func (s *service) CancelGroup(ctx context.Context, groupID, requesterID string) error {
+	ctx, span := tracer.Start(ctx, "booking.Service.CancelGroup", trace.WithAttributes(attribute.String("group.id", groupID)))
+	defer span.End()
+
+	group, err := s.GetGroup(ctx, groupID)
+	if err != nil {
+		return err
+	}
+	if group.BookerID != requesterID {
+		return apperrors.ErrForbidden
+	}
+
+	for _, confirmedPass := range []bool{false, true} {
+		for _, seat := range group.Seats {
+			if keepsSeat(seat) != confirmedPass || seat.Status == "Canceled" || seat.Status == "Cancelled" {
+				continue
+			}
+			if err := s.CancelBooking(ctx, seat.ID); err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
 
-func (s *service) GetBookingStatus(bookingID string) (*BookingStatus, error) {
+// TransferBooking hands a confirmed booking to another registered user. Only the holder of the booking,
+// or the booker of a group seat, may transfer it. The recipient must not hold an active booking for the
+// same conference or session, nor a confirmed booking at the same time. Both users are notified.
+func (s *service) TransferBooking(ctx context.Context, bookingID string, req TransferBookingRequest, requesterID string) (*Booking, error) {
+	ctx, span := tracer.Start(ctx, "booking.Service.TransferBooking", trace.WithAttributes(attribute.String("booking.id", bookingID), attribute.String("user.id", req.ToUserID)))
+	defer span.End()
+
+	booking, err := s.bookingRepo.FindByID(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if booking.UserID != requesterID && (booking.BookerID == "" || booking.BookerID != requesterID) {
+		return nil, apperrors.ErrForbidden
+	}
+	// Unassigned group seats are handed out with AssignSeat instead
+	if booking.Status != "Confirmed" || booking.UserID == "" || booking.UserID == req.ToUserID {
+		return nil, ErrInvalidAction
+	}
+
+	// Find the recipient
+	if _, err := s.userRepo.FindByID(ctx, req.ToUserID); err != nil {
+		return nil, err
+	}
+
+	// The recipient must be free to hold the booking
+	if existing, err := s.bookingRepo.FindActiveBooking(ctx, req.ToUserID, booking.ConferenceID, booking.SessionID); err == nil {
+		return nil, errors.New("user already has an active booking with ID: " + existing.ID)
+	}
+	p, err := s.findPool(ctx, booking)
+	if err != nil {
+		return nil, err
+	}
+
+	// The repository checks the recipient's other seats in the same step as the transfer, so that
+	// two transfers cannot both hand them overlapping seats
+	fromUserID := booking.UserID
+	booking, err = s.bookingRepo.Transfer(ctx, booking.ID, fromUserID, req.ToUserID, p.start(), p.end(), time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	s.notify(ctx, fromUserID, "Booking transferred", "Your booking "+booking.ID+" for "+booking.ConferenceID+" now belongs to "+req.ToUserID+".")
+	s.notify(ctx, req.ToUserID, "Booking received", fromUserID+" transferred booking "+booking.ID+" for "+booking.ConferenceID+" to you.")
+	return booking, nil
+}
+
+func (s *service) GetBookingStatus(ctx context.Context, bookingID string) (*BookingStatus, error) {
+	ctx, span := tracer.Start(ctx, "booking.Service.GetBookingStatus", trace.WithAttributes(attribute.String("booking.id", bookingID)))
+	defer span.End()
+
 	// Find the booking
-	booking, err := s.bookingRepo.FindByID(bookingID)
+	booking, err := s.bookingRepo.FindByID(ctx, bookingID)
 	if err != nil {
 		return nil, err
 	}
 
 	if booking.Status == "Canceled" {
 		return &BookingStatus{
-			Status: booking.Status,
+			Status:  booking.Status,
+			OrderID: booking.OrderID,
+			Refund:  booking.Refund,
 		}, nil
 	}
 
-	if booking.Status == "Waitlisted" && booking.WaitlistUntil.Before(time.Now()) {
+	if (booking.Status == "Waitlisted" || booking.Status == "PendingConfirmation") && booking.WaitlistUntil.Before(time.Now()) {
 		return &BookingStatus{
 			Status: "Expired",
 		}, nil
@@ -203,46 +679,240 @@ func (s *service) GetBookingStatus(bookingID string) (*BookingStatus, error) {
 	return &BookingStatus{
 		Status:        booking.Status,
 		WaitlistUntil: booking.WaitlistUntil,
+		OrderID:       booking.OrderID,
+		Seat:          booking.Seat,
 	}, nil
 }
 
+// ListBookings lists bookings. The owner of the conference filtered by sees all its bookings;
+// everyone else sees only their own, and the bookings of a draft conference not at all.
+func (s *service) ListBookings(ctx context.Context, q query.Query, requesterID string) (query.Page[*Booking], error) {
+	ctx, span := tracer.Start(ctx, "booking.Service.ListBookings")
+	defer span.End()
+
+	if q.ConferenceID != "" {
+		conf, err := s.confRepo.FindByName(ctx, q.ConferenceID)
+		if err != nil {
+			return query.Page[*Booking]{}, err
+		}
+		if !conf.VisibleTo(requesterID) {
+			return query.Page[*Booking]{}, apperrors.ErrNotFound
+		}
+		if conf.OwnerID != "" && conf.OwnerID == requesterID {
+			return s.bookingRepo.List(ctx, q)
+		}
+	}
+
+	if requesterID == "" || (q.UserID != "" && q.UserID != requesterID) {
+		return query.Page[*Booking]{}, apperrors.ErrForbidden
+	}
+	q.UserID = requesterID
+	return s.bookingRepo.List(ctx, q)
+}
+
+// GetRoster returns all bookings of a conference with user details.
+// Only the owner of the conference may see it.
+func (s *service) GetRoster(ctx context.Context, conferenceName, requesterID string) (*Roster, error) {
+	ctx, span := tracer.Start(ctx, "booking.Service.GetRoster", trace.WithAttributes(attribute.String("conference.id", conferenceName)))
+	defer span.End()
+
+	conf, err := s.confRepo.FindByName(ctx, conferenceName)
+	if err != nil {
+		return nil, err
+	}
+	if conf.OwnerID == "" || conf.OwnerID != requesterID {
+		return nil, apperrors.ErrForbidden
+	}
+
+	roster := &Roster{
+		Conference:     conf.Name,
+		Confirmed:      []*Attendee{},
+		Attended:       []*Attendee{},
+		PendingPayment: []*Attendee{},
+		Waitlisted:     []*Attendee{},
+		Cancelled:      []*Attendee{},
+	}
+	for _, booking := range s.bookingRepo.FindByConference(ctx, conf.Name) {
+		attendee := &Attendee{
+			BookingID:     booking.ID,
+			SessionID:     booking.SessionID,
+			TicketType:    booking.TicketType,
+			Status:        booking.Status,
+			WaitlistUntil: booking.WaitlistUntil,
+			CreatedAt:     booking.CreatedAt,
+			User:          &user.User{ID: booking.UserID},
+		}
+		if u, err := s.userRepo.FindByID(ctx, booking.UserID); err == nil {
+			attendee.User = u
+		}
+
+		switch booking.Status {
+		case "Confirmed":
+			roster.Confirmed = append(roster.Confirmed, attendee)
+		case "Attended":
+			roster.Attended = append(roster.Attended, attendee)
+		case "PendingPayment":
+			roster.PendingPayment = append(roster.PendingPayment, attendee)
+		case "Waitlisted", "PendingConfirmation":
+			roster.Waitlisted = append(roster.Waitlisted, attendee)
+		default:
+			roster.Cancelled = append(roster.Cancelled, attendee)
+		}
+	}
+	return roster, nil
+}
+
+// GetUserCalendar returns the confirmed and attended bookings of a user, authorised by the user's
+// calendar token.
+func (s *service) GetUserCalendar(ctx context.Context, userID, token string) ([]*CalendarEntry, error) {
+	ctx, span := tracer.Start(ctx, "booking.Service.GetUserCalendar", trace.WithAttributes(attribute.String("user.id", userID)))
+	defer span.End()
+
+	u, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if u.CalendarToken == "" || subtle.ConstantTimeCompare([]byte(u.CalendarToken), []byte(token)) != 1 {
+		return nil, apperrors.ErrForbidden
+	}
+
+	page, err := s.bookingRepo.List(ctx, query.Query{UserID: userID, Limit: query.MaxLimit})
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []*CalendarEntry{}
+	for {
+		for _, booking := range page.Items {
+			if booking.Status != "Confirmed" && booking.Status != "Attended" {
+				continue
+			}
+			conf, err := s.confRepo.FindByName(ctx, booking.ConferenceID)
+			if err != nil {
+				continue // Skip if conference not found
+			}
+			entry := &CalendarEntry{BookingID: booking.ID, Conference: conf}
+			if booking.SessionID != "" {
+				if entry.Session, err = s.confRepo.FindSession(ctx, booking.SessionID); err != nil {
+					continue // Skip if session not found
+				}
+			}
+			entries = append(entries, entry)
+		}
+		if page.NextCursor == "" {
+			return entries, nil
+		}
+		page, err = s.bookingRepo.List(ctx, query.Query{UserID: userID, Limit: query.MaxLimit, Cursor: page.NextCursor})
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
 func (s *service) StartBookingCleanup(interval time.Duration) {
 	go func() {
 		for {
 			time.Sleep(interval) // Wait for the specified interval
-			s.cleanupBookings()
+			s.cleanupBookings(context.Background())
 		}
 	}()
 }
 
-func (s *service) cleanupBookings() {
-	bookings := s.bookingRepo.GetAllBookings()
+func (s *service) cleanupBookings(ctx context.Context) {
+	ctx, span := tracer.Start(ctx, "booking.Service.cleanupBookings")
+	defer span.End()
+
+	// Return the unused reserved seats of expired codes
+	s.releaseExpiredReservations(ctx, time.Now())
+
+	// Draw lotteries whose registration has closed
+	s.drawDueLotteries(ctx, time.Now())
+
+	// Book series bookings into newly generated occurrences
+	s.bookNewOccurrences(ctx, time.Now())
+
+	// Release the seats of holds that were not committed in time
+	for _, hold := range s.bookingRepo.FindExpiredHolds(ctx, time.Now()) {
+		_ = s.releaseHold(ctx, hold)
+	}
+
+	// Release the seats of orders that were not paid in time
+	for _, order := range s.bookingRepo.FindExpiredOrders(ctx, time.Now()) {
+		_ = s.releaseOrder(ctx, order, OrderPending, OrderExpired)
+	}
+
+	bookings := s.bookingRepo.GetAllBookings(ctx)
 
 	for _, booking := range bookings {
 		// Remove expired waitlisted bookings
 		if booking.Status == "Waitlisted" && booking.WaitlistUntil != nil && booking.WaitlistUntil.Before(time.Now().UTC()) {
 			booking.Status = "Canceled"
-			s.bookingRepo.Update(booking)
+			s.bookingRepo.Update(ctx, booking)
+			_ = s.returnCodeUse(ctx, booking)
+			continue
+		}
+
+		// Pass on the seats kept for promoted bookings that were not confirmed in time
+		if booking.Status == "PendingConfirmation" && booking.WaitlistUntil != nil && booking.WaitlistUntil.Before(time.Now().UTC()) {
+			if p, err := s.findPool(ctx, booking); err == nil {
+				_ = s.cancel(ctx, p, booking)
+			}
 			continue
 		}
 
 		// Remove confirmed bookings from overlapping waitlists
-		if booking.Status == "Confirmed" {
-			conf, err := s.confRepo.FindByName(booking.ConferenceID)
+		// (unassigned group seats have no user, session bookings only block sessions)
+		if booking.Status == "Confirmed" && booking.UserID != "" && booking.SessionID == "" {
+			conf, err := s.confRepo.FindByName(ctx, booking.ConferenceID)
 			if err != nil {
 				continue // Skip if conference not found
 			}
-			_ = s.bookingRepo.RemoveOverlappingWaitlists(booking.UserID, conf.StartTime, conf.EndTime)
+			for _, removed := range s.bookingRepo.RemoveOverlappingWaitlists(ctx, booking.UserID, conf.StartTime, conf.EndTime) {
+				_ = s.returnCodeUse(ctx, removed)
+			}
 		}
 
-		// Handle expired bookings based on conference timing
-		conf, err := s.confRepo.FindByName(booking.ConferenceID)
+		// Handle expired bookings based on conference timing:
+		// confirmed bookings never checked in become no-shows, everything else not attended is cancelled
+		conf, err := s.confRepo.FindByName(ctx, booking.ConferenceID)
 		if err != nil {
 			continue // Skip if conference not found
 		}
-		if conf.EndTime.Before(time.Now().UTC()) {
+		if !conf.EndTime.Before(time.Now().UTC()) {
+			continue
+		}
+		switch {
+		case booking.Status == "Confirmed" && booking.UserID != "":
+			_ = s.markNoShow(ctx, booking)
+		case booking.Status == "PendingPayment":
+			// Nobody paid before the end, so the order closes too; the seat is not offered on
+			// since the conference is over
+			booking.Status = "Canceled"
+			if err := s.bookingRepo.Update(ctx, booking); err == nil {
+				_ = s.closeOrderIfEmpty(ctx, booking.OrderID)
+			}
+		case booking.Status != "Attended" && booking.Status != "NoShow":
 			booking.Status = "Canceled"
-			s.bookingRepo.Update(booking)
+			s.bookingRepo.Update(ctx, booking)
 		}
 	}
 }
+
+// holdsSeat reports whether a booking takes a seat from its pool.
+func holdsSeat(booking *Booking) bool {
+	return booking.Status == "Confirmed" || booking.Status == "PendingPayment" || booking.Status == "Attended"
+}
+
+// keepsSeat reports whether a booking takes a seat or has one kept for it after a promotion
+// from the waitlist.
+func keepsSeat(booking *Booking) bool {
+	return holdsSeat(booking) || booking.Status == "PendingConfirmation"
+}
+
+// notify sends a notification to a user. Delivery failures are recorded on the span but never
+// undo the change the user is told about.
+func (s *service) notify(ctx context.Context, userID, subject, body string) {
+	if err := s.notifier.Notify(ctx, notification.Notification{UserID: userID, Subject: subject, Body: body}); err != nil {
+		trace.SpanFromContext(ctx).RecordError(err)
+	}
+}
@@ -1,5 +1,31 @@
 package booking
 
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"maps"
+	mathrand "math/rand"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"conference-booking/internal/conference"
+	"conference-booking/internal/notification"
+	"conference-booking/internal/payment"
+	"conference-booking/internal/user"
+	"conference-booking/pkg/auth"
+	"conference-booking/pkg/checkin"
+	apperrors "conference-booking/pkg/errors"
+	"conference-booking/pkg/query"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
 // import (
 // 	"conference-booking/internal/conference"
 // 	"conference-booking/internal/user"
@@ -53,3 +79,1652 @@ package booking
 // 	assert.NoError(t, err)
 // 	assert.Equal(t, "Canceled", updatedBooking.Status)
 // }
+
+// recordingNotifier keeps every notification it is asked to deliver.
+type recordingNotifier struct {
+	sent []notification.Notification
+}
+
+func (r *recordingNotifier) Notify(ctx context.Context, n notification.Notification) error {
+	r.sent = append(r.sent, n)
+	return nil
+}
+
+func setupService() (Service, conference.Repository, user.Repository) {
+	service, confRepo, userRepo, _ := setupServiceWithNotifier()
+	return service, confRepo, userRepo
+}
+
+func setupServiceWithNotifier() (Service, conference.Repository, user.Repository, *recordingNotifier) {
+	return setupServiceWithConfig(Config{})
+}
+
+func setupServiceWithConfig(cfg Config) (Service, conference.Repository, user.Repository, *recordingNotifier) {
+	confRepo := conference.NewInMemoryRepository()
+	userRepo := user.NewInMemoryRepository()
+	bookingRepo := NewInMemoryRepository(confRepo)
+	notifier := &recordingNotifier{}
+	signer, _ := checkin.NewSigner("test-secret")
+	return NewService(confRepo, userRepo, bookingRepo, payment.NewFakeProvider(), notifier, signer, cfg), confRepo, userRepo, notifier
+}
+
+func TestGetRosterRestrictedToOwner(t *testing.T) {
+	service, confRepo, userRepo := setupService()
+	ctx := context.Background()
+
+	// Add a conference with a single slot
+	assert.NoError(t, confRepo.Create(ctx, &conference.Conference{
+		Name:           "TechConf",
+		StartTime:      time.Now().Add(24 * time.Hour).UTC(),
+		EndTime:        time.Now().Add(26 * time.Hour).UTC(),
+		AvailableSlots: 1,
+		OwnerID:        "organiser",
+	}))
+
+	// Two users book: one confirmed, one waitlisted
+	for _, id := range []string{"user1", "user2"} {
+		assert.NoError(t, userRepo.Create(ctx, &user.User{ID: id}))
+		_, err := service.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: id})
+		assert.NoError(t, err)
+	}
+
+	// Only the owner sees the roster
+	_, err := service.GetRoster(ctx, "TechConf", "user1")
+	assert.ErrorIs(t, err, apperrors.ErrForbidden)
+
+	roster, err := service.GetRoster(ctx, "TechConf", "organiser")
+	assert.NoError(t, err)
+	assert.Len(t, roster.Confirmed, 1)
+	assert.Len(t, roster.Waitlisted, 1)
+	assert.Equal(t, "user1", roster.Confirmed[0].User.ID)
+}
+
+func TestExportRosterListsEverySeatHolderWithDetails(t *testing.T) {
+	confRepo := conference.NewInMemoryRepository()
+	userRepo := user.NewInMemoryRepository()
+	bookingRepo := NewInMemoryRepository(confRepo)
+	signer, _ := checkin.NewSigner("test-secret")
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	RegisterRoutes(router, confRepo, userRepo, bookingRepo, payment.NewFakeProvider(), &recordingNotifier{}, signer, Config{})
+	ctx := context.Background()
+
+	assert.NoError(t, confRepo.Create(ctx, &conference.Conference{
+		Name:           "TechConf",
+		StartTime:      time.Now().Add(24 * time.Hour).UTC(),
+		EndTime:        time.Now().Add(26 * time.Hour).UTC(),
+		AvailableSlots: 10,
+		OwnerID:        "organiser",
+	}))
+	statuses := map[string]string{"ada": "Confirmed", "bob": "Attended", "cy": "PendingPayment", "dee": "Waitlisted", "eve": "Canceled"}
+	for id, status := range statuses {
+		assert.NoError(t, userRepo.Create(ctx, &user.User{ID: id, Name: "Name of " + id, Email: id + "@example.com"}))
+		assert.NoError(t, bookingRepo.Create(ctx, &Booking{ID: "booking-" + id, UserID: id, ConferenceID: "TechConf", Status: status, CreatedAt: time.Now().UTC()}))
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/conference/TechConf/bookings/export?format=csv", nil)
+	req.Header.Set(auth.Header, "organiser")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"booking_id", "user_id", "name", "email", "status", "session_id", "ticket_type", "waitlist_until", "created_at"}, records[0])
+	exported := map[string][]string{}
+	for _, record := range records[1:] {
+		exported[record[1]] = record
+	}
+	assert.ElementsMatch(t, []string{"ada", "bob", "cy"}, slices.Collect(maps.Keys(exported)))
+	assert.Equal(t, []string{"Name of bob", "bob@example.com", "Attended"}, exported["bob"][2:5])
+}
+
+func TestSessionBookingRejectsConcurrentSessions(t *testing.T) {
+	service, confRepo, userRepo := setupService()
+	ctx := context.Background()
+	start := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Hour)
+
+	assert.NoError(t, confRepo.Create(ctx, &conference.Conference{
+		Name:           "TechConf",
+		StartTime:      start,
+		EndTime:        start.Add(8 * time.Hour),
+		AvailableSlots: 100,
+	}))
+	sessions := []*conference.Session{
+		{ID: "keynote", ConferenceName: "TechConf", StartTime: start, EndTime: start.Add(time.Hour), AvailableSlots: 1},
+		{ID: "workshop", ConferenceName: "TechConf", StartTime: start.Add(30 * time.Minute), EndTime: start.Add(2 * time.Hour), AvailableSlots: 10},
+		{ID: "talk", ConferenceName: "TechConf", StartTime: start.Add(time.Hour), EndTime: start.Add(2 * time.Hour), AvailableSlots: 10},
+	}
+	for _, session := range sessions {
+		assert.NoError(t, confRepo.CreateSession(ctx, session))
+	}
+	for _, id := range []string{"user1", "user2"} {
+		assert.NoError(t, userRepo.Create(ctx, &user.User{ID: id}))
+	}
+
+	// The keynote takes the single seat
+	_, err := service.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: "user1", SessionID: "keynote"})
+	assert.NoError(t, err)
+
+	// A session overlapping the keynote is rejected, one starting when it ends is not
+	_, err = service.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: "user1", SessionID: "workshop"})
+	assert.ErrorIs(t, err, ErrBookingConflict)
+	_, err = service.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: "user1", SessionID: "talk"})
+	assert.NoError(t, err)
+
+	// The full keynote has its own waitlist, independent of the conference pool
+	bookingID, err := service.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: "user2", SessionID: "keynote"})
+	assert.NoError(t, err)
+	status, err := service.GetBookingStatus(ctx, bookingID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Waitlisted", status.Status)
+
+	conf, err := confRepo.FindByName(ctx, "TechConf")
+	assert.NoError(t, err)
+	assert.Equal(t, 100, conf.AvailableSlots)
+}
+
+func TestBookConferenceRejectsOverlappingConferences(t *testing.T) {
+	service, confRepo, userRepo := setupService()
+	ctx := context.Background()
+	start := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Hour)
+
+	conferences := []*conference.Conference{
+		{Name: "Morning", StartTime: start, EndTime: start.Add(3 * time.Hour), AvailableSlots: 10},
+		{Name: "Overlapping", StartTime: start.Add(2 * time.Hour), EndTime: start.Add(5 * time.Hour), AvailableSlots: 10},
+		{Name: "BackToBack", StartTime: start.Add(3 * time.Hour), EndTime: start.Add(6 * time.Hour), AvailableSlots: 10},
+		{Name: "Full", StartTime: start.Add(time.Hour), EndTime: start.Add(2 * time.Hour), AvailableSlots: 0},
+	}
+	for _, conf := range conferences {
+		assert.NoError(t, confRepo.Create(ctx, conf))
+	}
+	assert.NoError(t, userRepo.Create(ctx, &user.User{ID: "user1"}))
+
+	morningID, err := service.BookConference(ctx, BookConferenceRequest{ConferenceName: "Morning", UserID: "user1"})
+	assert.NoError(t, err)
+
+	// Overlap is rejected and names the conflicting booking
+	_, err = service.BookConference(ctx, BookConferenceRequest{ConferenceName: "Overlapping", UserID: "user1"})
+	var overlap *OverlapError
+	assert.ErrorAs(t, err, &overlap)
+	assert.ErrorIs(t, err, ErrBookingConflict)
+	assert.Equal(t, morningID, overlap.Conflicting.ID)
+
+	// A conference starting exactly when the other ends does not overlap
+	_, err = service.BookConference(ctx, BookConferenceRequest{ConferenceName: "BackToBack", UserID: "user1"})
+	assert.NoError(t, err)
+
+	// The flag allows a deliberate overlap
+	_, err = service.BookConference(ctx, BookConferenceRequest{ConferenceName: "Overlapping", UserID: "user1", AllowOverlap: true})
+	assert.NoError(t, err)
+
+	// Waitlisting is allowed, but confirming the overlapping waitlist entry is not
+	waitlistedID, err := service.BookConference(ctx, BookConferenceRequest{ConferenceName: "Full", UserID: "user1"})
+	assert.NoError(t, err)
+	full, _ := confRepo.FindByName(ctx, "Full")
+	full.AvailableSlots = 1
+	assert.NoError(t, confRepo.Update(ctx, full))
+	err = service.ConfirmWaitlistBooking(ctx, ConfirmWaitlistRequest{BookingID: waitlistedID})
+	assert.ErrorIs(t, err, ErrBookingConflict)
+}
+
+func TestOverlapCountsUnpaidSeatsAndComparesSessionsWithConferences(t *testing.T) {
+	service, confRepo, userRepo := setupService()
+	ctx := context.Background()
+	start := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Hour)
+
+	for _, conf := range []*conference.Conference{
+		{Name: "Paid", StartTime: start, EndTime: start.Add(3 * time.Hour), AvailableSlots: 10},
+		{Name: "Free", StartTime: start.Add(time.Hour), EndTime: start.Add(4 * time.Hour), AvailableSlots: 10},
+		{Name: "Summit", StartTime: start.Add(2 * time.Hour), EndTime: start.Add(8 * time.Hour), AvailableSlots: 10},
+	} {
+		assert.NoError(t, confRepo.Create(ctx, conf))
+	}
+	assert.NoError(t, confRepo.CreateTicketType(ctx, &conference.TicketType{ConferenceName: "Paid", Name: "Regular", Capacity: 10, AvailableSlots: 10, Price: 4900, Currency: "EUR"}))
+	assert.NoError(t, confRepo.CreateSession(ctx, &conference.Session{ID: "talk", ConferenceName: "Summit", StartTime: start.Add(2 * time.Hour), EndTime: start.Add(3 * time.Hour), AvailableSlots: 10}))
+	assert.NoError(t, confRepo.CreateSession(ctx, &conference.Session{ID: "late", ConferenceName: "Summit", StartTime: start.Add(5 * time.Hour), EndTime: start.Add(6 * time.Hour), AvailableSlots: 10}))
+	assert.NoError(t, userRepo.Create(ctx, &user.User{ID: "user1"}))
+
+	// A seat awaiting payment already blocks its time
+	paidID, err := service.BookConference(ctx, BookConferenceRequest{ConferenceName: "Paid", UserID: "user1", TicketType: "Regular"})
+	assert.NoError(t, err)
+	status, err := service.GetBookingStatus(ctx, paidID)
+	assert.NoError(t, err)
+	assert.Equal(t, "PendingPayment", status.Status)
+	_, err = service.BookConference(ctx, BookConferenceRequest{ConferenceName: "Free", UserID: "user1"})
+	assert.ErrorIs(t, err, ErrBookingConflict)
+
+	// A session clashes with another conference, but not with its own
+	_, err = service.BookConference(ctx, BookConferenceRequest{ConferenceName: "Summit", UserID: "user1", SessionID: "talk"})
+	assert.ErrorIs(t, err, ErrBookingConflict)
+	_, err = service.BookConference(ctx, BookConferenceRequest{ConferenceName: "Summit", UserID: "user1", SessionID: "late"})
+	assert.NoError(t, err)
+	_, err = service.BookConference(ctx, BookConferenceRequest{ConferenceName: "Summit", UserID: "user1", AllowOverlap: true})
+	assert.NoError(t, err)
+}
+
+func TestGroupBookingWaitlistsOverflowAndReleasesSeats(t *testing.T) {
+	service, confRepo, userRepo := setupService()
+	ctx := context.Background()
+
+	assert.NoError(t, confRepo.Create(ctx, &conference.Conference{
+		Name:           "TechConf",
+		StartTime:      time.Now().Add(24 * time.Hour).UTC(),
+		EndTime:        time.Now().Add(26 * time.Hour).UTC(),
+		Capacity:       3,
+		AvailableSlots: 3,
+	}))
+	for _, id := range []string{"booker", "attendee", "other"} {
+		assert.NoError(t, userRepo.Create(ctx, &user.User{ID: id}))
+	}
+	otherID, err := service.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: "other"})
+	assert.NoError(t, err)
+
+	// Groups larger than the conference are rejected
+	_, err = service.BookGroup(ctx, BookGroupRequest{ConferenceName: "TechConf", BookerID: "booker", Seats: 1 << 40})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidInput)
+
+	// Three seats with two left: two confirmed, one waitlisted
+	group, err := service.BookGroup(ctx, BookGroupRequest{ConferenceName: "TechConf", BookerID: "booker", Seats: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, group.Confirmed)
+	assert.Equal(t, 1, group.Waitlisted)
+
+	// Only the booker assigns seats
+	var seat, waitlisted *Booking
+	for _, s := range group.Seats {
+		switch {
+		case s.Status == "Confirmed" && seat == nil:
+			seat = s
+		case s.Status == "Waitlisted":
+			waitlisted = s
+		}
+	}
+	_, err = service.AssignSeat(ctx, group.ID, AssignSeatRequest{BookingID: seat.ID, UserID: "attendee"}, "attendee")
+	assert.ErrorIs(t, err, apperrors.ErrForbidden)
+	assigned, err := service.AssignSeat(ctx, group.ID, AssignSeatRequest{BookingID: seat.ID, UserID: "attendee"}, "booker")
+	assert.NoError(t, err)
+	assert.Equal(t, "attendee", assigned.UserID)
+
+	// The unassigned waitlisted seat is promoted and confirmed next to its unassigned siblings
+	assert.NoError(t, service.CancelBooking(ctx, otherID))
+	assert.NoError(t, service.ConfirmWaitlistBooking(ctx, ConfirmWaitlistRequest{BookingID: waitlisted.ID}))
+	group, err = service.GetGroup(ctx, group.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, group.Confirmed)
+
+	// Another user joins the waitlist, then the whole group is cancelled
+	otherID, err = service.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: "other"})
+	assert.NoError(t, err)
+	assert.NoError(t, service.CancelGroup(ctx, group.ID, "booker"))
+
+	// One released seat is kept for the waitlisted user, the others go back to the pool
+	conf, err := confRepo.FindByName(ctx, "TechConf")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, conf.AvailableSlots)
+	status, err := service.GetBookingStatus(ctx, otherID)
+	assert.NoError(t, err)
+	assert.Equal(t, "PendingConfirmation", status.Status)
+	assert.NoError(t, service.ConfirmWaitlistBooking(ctx, ConfirmWaitlistRequest{BookingID: otherID}))
+	status, err = service.GetBookingStatus(ctx, otherID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Confirmed", status.Status)
+	assert.Equal(t, 2, conf.AvailableSlots)
+}
+
+func TestTicketTypesHaveSeparateCapacityAndSaleWindows(t *testing.T) {
+	service, confRepo, userRepo := setupService()
+	ctx := context.Background()
+	closed := time.Now().Add(-time.Hour)
+
+	assert.NoError(t, confRepo.Create(ctx, &conference.Conference{
+		Name:           "TechConf",
+		StartTime:      time.Now().Add(24 * time.Hour).UTC(),
+		EndTime:        time.Now().Add(26 * time.Hour).UTC(),
+		AvailableSlots: 100,
+	}))
+	assert.NoError(t, confRepo.CreateTicketType(ctx, &conference.TicketType{ConferenceName: "TechConf", Name: "Student", Capacity: 1, AvailableSlots: 1}))
+	assert.NoError(t, confRepo.CreateTicketType(ctx, &conference.TicketType{ConferenceName: "TechConf", Name: "EarlyBird", Capacity: 10, AvailableSlots: 10, SalesEnd: &closed}))
+	for _, id := range []string{"user1", "user2"} {
+		assert.NoError(t, userRepo.Create(ctx, &user.User{ID: id}))
+	}
+
+	// A conference with ticket types needs one, and only while it is on sale
+	_, err := service.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: "user1"})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidInput)
+	_, err = service.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: "user1", TicketType: "EarlyBird"})
+	assert.ErrorIs(t, err, ErrTicketSaleClosed)
+
+	// The single student ticket goes to the first user, the second is waitlisted for that type
+	_, err = service.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: "user1", TicketType: "Student"})
+	assert.NoError(t, err)
+	waitlistedID, err := service.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: "user2", TicketType: "Student"})
+	assert.NoError(t, err)
+	status, err := service.GetBookingStatus(ctx, waitlistedID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Waitlisted", status.Status)
+
+	student, err := confRepo.FindTicketType(ctx, "TechConf", "Student")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, student.AvailableSlots)
+}
+
+func TestPaidTicketsHoldSeatUntilPaid(t *testing.T) {
+	service, confRepo, userRepo := setupService()
+	ctx := context.Background()
+
+	assert.NoError(t, confRepo.Create(ctx, &conference.Conference{
+		Name:           "TechConf",
+		StartTime:      time.Now().Add(24 * time.Hour).UTC(),
+		EndTime:        time.Now().Add(26 * time.Hour).UTC(),
+		AvailableSlots: 100,
+	}))
+	assert.NoError(t, confRepo.CreateTicketType(ctx, &conference.TicketType{ConferenceName: "TechConf", Name: "Regular", Capacity: 1, AvailableSlots: 1, Price: 4900, Currency: "EUR"}))
+	for _, id := range []string{"user1", "user2"} {
+		assert.NoError(t, userRepo.Create(ctx, &user.User{ID: id}))
+	}
+
+	// The booking holds the only seat while its order is pending
+	bookingID, err := service.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: "user1", TicketType: "Regular"})
+	assert.NoError(t, err)
+	status, err := service.GetBookingStatus(ctx, bookingID)
+	assert.NoError(t, err)
+	assert.Equal(t, "PendingPayment", status.Status)
+	waitlistedID, err := service.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: "user2", TicketType: "Regular"})
+	assert.NoError(t, err)
+
+	// Only the payer sees and pays the order
+	_, err = service.GetOrder(ctx, status.OrderID, "user2")
+	assert.ErrorIs(t, err, apperrors.ErrForbidden)
+	order, err := service.PayOrder(ctx, status.OrderID, PayOrderRequest{Token: "tok_visa"}, "user1")
+	assert.NoError(t, err)
+	assert.Equal(t, OrderPaid, order.Status)
+	assert.Equal(t, int64(4900), order.Amount)
+	assert.Equal(t, "EUR", order.Currency)
+
+	status, err = service.GetBookingStatus(ctx, bookingID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Confirmed", status.Status)
+	status, err = service.GetBookingStatus(ctx, waitlistedID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Waitlisted", status.Status)
+}
+
+func TestFailedOrExpiredPaymentReleasesSeat(t *testing.T) {
+	svc, confRepo, userRepo := setupService()
+	ctx := context.Background()
+
+	assert.NoError(t, confRepo.Create(ctx, &conference.Conference{
+		Name:           "TechConf",
+		StartTime:      time.Now().Add(24 * time.Hour).UTC(),
+		EndTime:        time.Now().Add(26 * time.Hour).UTC(),
+		AvailableSlots: 100,
+	}))
+	assert.NoError(t, confRepo.CreateTicketType(ctx, &conference.TicketType{ConferenceName: "TechConf", Name: "Regular", Capacity: 1, AvailableSlots: 1, Price: 4900, Currency: "EUR"}))
+	for _, id := range []string{"user1", "user2"} {
+		assert.NoError(t, userRepo.Create(ctx, &user.User{ID: id}))
+	}
+
+	// A declined payment cancels the booking and offers the seat to the waitlist
+	bookingID, err := svc.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: "user1", TicketType: "Regular"})
+	assert.NoError(t, err)
+	waitlistedID, err := svc.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: "user2", TicketType: "Regular"})
+	assert.NoError(t, err)
+	status, err := svc.GetBookingStatus(ctx, bookingID)
+	assert.NoError(t, err)
+
+	_, err = svc.PayOrder(ctx, status.OrderID, PayOrderRequest{Token: payment.DeclineToken}, "user1")
+	assert.ErrorIs(t, err, payment.ErrDeclined)
+	order, err := svc.GetOrder(ctx, status.OrderID, "user1")
+	assert.NoError(t, err)
+	assert.Equal(t, OrderFailed, order.Status)
+	status, err = svc.GetBookingStatus(ctx, bookingID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Canceled", status.Status)
+	status, err = svc.GetBookingStatus(ctx, waitlistedID)
+	assert.NoError(t, err)
+	assert.Equal(t, "PendingConfirmation", status.Status)
+
+	// The seat is kept for the promoted user, who confirms and now has to pay
+	retryID, err := svc.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: "user1", TicketType: "Regular"})
+	assert.NoError(t, err)
+	status, err = svc.GetBookingStatus(ctx, retryID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Waitlisted", status.Status)
+	assert.NoError(t, svc.ConfirmWaitlistBooking(ctx, ConfirmWaitlistRequest{BookingID: waitlistedID}))
+	status, err = svc.GetBookingStatus(ctx, waitlistedID)
+	assert.NoError(t, err)
+	assert.Equal(t, "PendingPayment", status.Status)
+
+	// An order left unpaid past its deadline is expired by the cleanup worker
+	order, err = svc.GetOrder(ctx, status.OrderID, "user2")
+	assert.NoError(t, err)
+	order.ExpiresAt = time.Now().Add(-time.Minute)
+
+	svc.(*service).cleanupBookings(ctx)
+	assert.Equal(t, OrderExpired, order.Status)
+	status, err = svc.GetBookingStatus(ctx, waitlistedID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Canceled", status.Status)
+
+	// A promotion that is not confirmed in time passes the seat on, here back to the pool
+	retry, err := svc.(*service).bookingRepo.FindByID(ctx, retryID)
+	assert.NoError(t, err)
+	assert.Equal(t, "PendingConfirmation", retry.Status)
+	expired := time.Now().Add(-time.Minute)
+	retry.WaitlistUntil = &expired
+	svc.(*service).cleanupBookings(ctx)
+	assert.ErrorIs(t, svc.ConfirmWaitlistBooking(ctx, ConfirmWaitlistRequest{BookingID: retryID}), ErrWaitlistExpired)
+	assert.Equal(t, "Canceled", retry.Status)
+	regular, err := confRepo.FindTicketType(ctx, "TechConf", "Regular")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, regular.AvailableSlots)
+}
+
+func TestUnpaidOrdersCloseWhenTheConferenceEnds(t *testing.T) {
+	svc, confRepo, userRepo := setupService()
+	ctx := context.Background()
+
+	assert.NoError(t, confRepo.Create(ctx, &conference.Conference{
+		Name:           "TechConf",
+		StartTime:      time.Now().Add(24 * time.Hour).UTC(),
+		EndTime:        time.Now().Add(26 * time.Hour).UTC(),
+		AvailableSlots: 10,
+	}))
+	assert.NoError(t, confRepo.CreateTicketType(ctx, &conference.TicketType{ConferenceName: "TechConf", Name: "Regular", Capacity: 10, AvailableSlots: 10, Price: 4900, Currency: "EUR"}))
+	assert.NoError(t, userRepo.Create(ctx, &user.User{ID: "user1"}))
+	bookingID, err := svc.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: "user1", TicketType: "Regular"})
+	assert.NoError(t, err)
+	status, err := svc.GetBookingStatus(ctx, bookingID)
+	assert.NoError(t, err)
+
+	// The conference ends while the order is still within its payment window
+	conf, err := confRepo.FindByName(ctx, "TechConf")
+	assert.NoError(t, err)
+	conf.StartTime, conf.EndTime = time.Now().Add(-3*time.Hour).UTC(), time.Now().Add(-time.Hour).UTC()
+	order, err := svc.GetOrder(ctx, status.OrderID, "user1")
+	assert.NoError(t, err)
+	assert.True(t, order.ExpiresAt.After(time.Now()))
+
+	svc.(*service).cleanupBookings(ctx)
+	status, err = svc.GetBookingStatus(ctx, bookingID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Canceled", status.Status)
+	assert.Equal(t, OrderCancelled, order.Status)
+	_, err = svc.PayOrder(ctx, order.ID, PayOrderRequest{Token: "tok_visa"}, "user1")
+	assert.Error(t, err)
+}
+
+// racingProvider runs during a charge what would otherwise race with it, and fails refunds while
+// refundErr is set.
+type racingProvider struct {
+	payment.Provider
+	duringCharge func()
+	refundErr    error
+}
+
+func (p *racingProvider) Refund(ctx context.Context, req payment.RefundRequest) (*payment.Refund, error) {
+	if p.refundErr != nil {
+		return nil, p.refundErr
+	}
+	return p.Provider.Refund(ctx, req)
+}
+
+func (p *racingProvider) Charge(ctx context.Context, req payment.ChargeRequest) (*payment.Charge, error) {
+	if p.duringCharge != nil {
+		p.duringCharge()
+	}
+	return p.Provider.Charge(ctx, req)
+}
+
+func TestPayOrderIsChargedOnceAndRefundsSeatsCancelledMeanwhile(t *testing.T) {
+	confRepo := conference.NewInMemoryRepository()
+	userRepo := user.NewInMemoryRepository()
+	payments := &racingProvider{Provider: payment.NewFakeProvider()}
+	signer, _ := checkin.NewSigner("test-secret")
+	svc := NewService(confRepo, userRepo, NewInMemoryRepository(confRepo), payments, &recordingNotifier{}, signer, Config{})
+	ctx := context.Background()
+
+	assert.NoError(t, confRepo.Create(ctx, &conference.Conference{
+		Name:           "TechConf",
+		StartTime:      time.Now().Add(24 * time.Hour).UTC(),
+		EndTime:        time.Now().Add(26 * time.Hour).UTC(),
+		AvailableSlots: 100,
+	}))
+	assert.NoError(t, confRepo.CreateTicketType(ctx, &conference.TicketType{ConferenceName: "TechConf", Name: "Regular", Capacity: 10, AvailableSlots: 10, Price: 4900, Currency: "EUR"}))
+	assert.NoError(t, userRepo.Create(ctx, &user.User{ID: "booker"}))
+
+	// While the charge is in flight, a second payment is rejected, expiry leaves the order alone
+	// and one seat is cancelled
+	group, err := svc.BookGroup(ctx, BookGroupRequest{ConferenceName: "TechConf", BookerID: "booker", TicketType: "Regular", Seats: 2})
+	assert.NoError(t, err)
+	orderID := group.Seats[0].OrderID
+	payments.duringCharge = func() {
+		_, err := svc.PayOrder(ctx, orderID, PayOrderRequest{Token: "tok_visa"}, "booker")
+		assert.ErrorIs(t, err, ErrInvalidAction)
+		order, err := svc.GetOrder(ctx, orderID, "booker")
+		assert.NoError(t, err)
+		assert.Equal(t, OrderPaying, order.Status)
+		order.ExpiresAt = time.Now().Add(-time.Minute)
+		svc.(*service).cleanupBookings(ctx)
+		assert.NoError(t, svc.CancelBooking(ctx, group.Seats[1].ID))
+	}
+	order, err := svc.PayOrder(ctx, orderID, PayOrderRequest{Token: "tok_visa"}, "booker")
+	assert.NoError(t, err)
+	assert.Equal(t, OrderPaid, order.Status)
+	assert.Equal(t, int64(4900), order.Refunded)
+	status, err := svc.GetBookingStatus(ctx, group.Seats[0].ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Confirmed", status.Status)
+	status, err = svc.GetBookingStatus(ctx, group.Seats[1].ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Canceled", status.Status)
+	assert.Equal(t, int64(4900), status.Refund.Amount)
+
+	// When every seat is gone by the time the charge succeeds, the whole charge is refunded
+	seat, err := svc.BookGroup(ctx, BookGroupRequest{ConferenceName: "TechConf", BookerID: "booker", TicketType: "Regular", Seats: 1})
+	assert.NoError(t, err)
+	payments.duringCharge = func() { assert.NoError(t, svc.CancelBooking(ctx, seat.Seats[0].ID)) }
+	_, err = svc.PayOrder(ctx, seat.Seats[0].OrderID, PayOrderRequest{Token: "tok_visa"}, "booker")
+	assert.ErrorIs(t, err, ErrOrderCancelled)
+	order, err = svc.GetOrder(ctx, seat.Seats[0].OrderID, "booker")
+	assert.NoError(t, err)
+	assert.Equal(t, OrderCancelled, order.Status)
+	assert.Equal(t, order.Amount, order.Refunded)
+}
+
+func TestCancellationPolicyRefundsAndClosesAtStart(t *testing.T) {
+	service, confRepo, userRepo := setupService()
+	ctx := context.Background()
+
+	// Free until 7 days before, half refunded from 2 days before
+	assert.NoError(t, confRepo.Create(ctx, &conference.Conference{
+		Name:           "TechConf",
+		StartTime:      time.Now().Add(72 * time.Hour).UTC(),
+		EndTime:        time.Now().Add(74 * time.Hour).UTC(),
+		AvailableSlots: 100,
+		CancellationPolicy: &conference.CancellationPolicy{
+			FreeUntilDays: 7,
+			RefundWindows: []conference.RefundWindow{{DaysBefore: 2, Percent: 50}},
+		},
+	}))
+	assert.NoError(t, confRepo.Create(ctx, &conference.Conference{
+		Name:           "Started",
+		StartTime:      time.Now().Add(-time.Hour).UTC(),
+		EndTime:        time.Now().Add(time.Hour).UTC(),
+		AvailableSlots: 10,
+	}))
+	assert.NoError(t, confRepo.CreateTicketType(ctx, &conference.TicketType{ConferenceName: "TechConf", Name: "Regular", Capacity: 10, AvailableSlots: 10, Price: 5000, Currency: "EUR"}))
+	assert.NoError(t, userRepo.Create(ctx, &user.User{ID: "user1"}))
+
+	bookingID, err := service.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: "user1", TicketType: "Regular"})
+	assert.NoError(t, err)
+	status, err := service.GetBookingStatus(ctx, bookingID)
+	assert.NoError(t, err)
+	_, err = service.PayOrder(ctx, status.OrderID, PayOrderRequest{Token: "tok_visa"}, "user1")
+	assert.NoError(t, err)
+
+	// Three days before the start only the partial window applies
+	assert.NoError(t, service.CancelBooking(ctx, bookingID))
+	status, err = service.GetBookingStatus(ctx, bookingID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Canceled", status.Status)
+	assert.Equal(t, 50, status.Refund.Percent)
+	assert.Equal(t, int64(2500), status.Refund.Amount)
+	order, err := service.GetOrder(ctx, status.OrderID, "user1")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2500), order.Refunded)
+
+	// Nothing can be cancelled once the conference has started
+	started, err := confRepo.FindByName(ctx, "Started")
+	assert.NoError(t, err)
+	startTime := started.StartTime
+	started.StartTime = time.Now().Add(time.Hour)
+	startedID, err := service.BookConference(ctx, BookConferenceRequest{ConferenceName: "Started", UserID: "user1", AllowOverlap: true})
+	assert.NoError(t, err)
+	started.StartTime = startTime
+	assert.ErrorIs(t, service.CancelBooking(ctx, startedID), ErrCancellationClosed)
+}
+
+func TestInviteOnlyConferenceRequiresValidCode(t *testing.T) {
+	service, confRepo, userRepo := setupService()
+	ctx := context.Background()
+
+	// One free seat plus one seat reserved for the code, which can be used twice
+	assert.NoError(t, confRepo.Create(ctx, &conference.Conference{
+		Name:           "Workshop",
+		StartTime:      time.Now().Add(24 * time.Hour).UTC(),
+		EndTime:        time.Now().Add(26 * time.Hour).UTC(),
+		AvailableSlots: 1,
+		InviteOnly:     true,
+	}))
+	assert.NoError(t, confRepo.CreatePromoCode(ctx, &conference.PromoCode{ConferenceName: "Workshop", Code: "INVITE", MaxUses: 2, ReservedSeats: 1, AvailableSlots: 1}))
+	for _, id := range []string{"user1", "user2", "user3"} {
+		assert.NoError(t, userRepo.Create(ctx, &user.User{ID: id}))
+	}
+
+	_, err := service.BookConference(ctx, BookConferenceRequest{ConferenceName: "Workshop", UserID: "user1"})
+	assert.ErrorIs(t, err, ErrInviteRequired)
+	_, err = service.BookConference(ctx, BookConferenceRequest{ConferenceName: "Workshop", UserID: "user1", Code: "WRONG"})
+	assert.ErrorIs(t, err, ErrInvalidCode)
+
+	// The first holder takes the reserved seat, the second a regular one
+	_, err = service.BookConference(ctx, BookConferenceRequest{ConferenceName: "Workshop", UserID: "user1", Code: "INVITE"})
+	assert.NoError(t, err)
+	_, err = service.BookConference(ctx, BookConferenceRequest{ConferenceName: "Workshop", UserID: "user2", Code: "INVITE"})
+	assert.NoError(t, err)
+	code, err := confRepo.FindPromoCode(ctx, "Workshop", "INVITE")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code.AvailableSlots)
+	assert.Equal(t, 2, code.Uses)
+	conf, err := confRepo.FindByName(ctx, "Workshop")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, conf.AvailableSlots)
+
+	// The code is used up
+	_, err = service.BookConference(ctx, BookConferenceRequest{ConferenceName: "Workshop", UserID: "user3", Code: "INVITE"})
+	assert.ErrorIs(t, err, ErrInvalidCode)
+}
+
+func TestCodeUsesAreReturnedWhenEntriesEndWithoutASeat(t *testing.T) {
+	svc, confRepo, userRepo := setupService()
+	ctx := context.Background()
+
+	assert.NoError(t, confRepo.Create(ctx, &conference.Conference{
+		Name:           "Workshop",
+		StartTime:      time.Now().Add(24 * time.Hour).UTC(),
+		EndTime:        time.Now().Add(26 * time.Hour).UTC(),
+		AvailableSlots: 1,
+		InviteOnly:     true,
+	}))
+	assert.NoError(t, confRepo.CreatePromoCode(ctx, &conference.PromoCode{ConferenceName: "Workshop", Code: "INVITE", MaxUses: 2}))
+	for _, id := range []string{"user1", "user2", "user3"} {
+		assert.NoError(t, userRepo.Create(ctx, &user.User{ID: id}))
+	}
+	uses := func() int {
+		code, err := confRepo.FindPromoCode(ctx, "Workshop", "INVITE")
+		assert.NoError(t, err)
+		return code.Uses
+	}
+
+	// The seat and a waitlist entry take both uses
+	confirmedID, err := svc.BookConference(ctx, BookConferenceRequest{ConferenceName: "Workshop", UserID: "user1", Code: "INVITE"})
+	assert.NoError(t, err)
+	waitlistedID, err := svc.BookConference(ctx, BookConferenceRequest{ConferenceName: "Workshop", UserID: "user2", Code: "INVITE"})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, uses())
+	_, err = svc.BookConference(ctx, BookConferenceRequest{ConferenceName: "Workshop", UserID: "user3", Code: "INVITE"})
+	assert.ErrorIs(t, err, ErrInvalidCode)
+
+	// Leaving the waitlist gives the use back
+	assert.NoError(t, svc.CancelBooking(ctx, waitlistedID))
+	assert.Equal(t, 1, uses())
+
+	// So does a waitlist entry that expires, but not a seat that was taken
+	waitlistedID, err = svc.BookConference(ctx, BookConferenceRequest{ConferenceName: "Workshop", UserID: "user3", Code: "INVITE"})
+	assert.NoError(t, err)
+	booking, err := svc.(*service).bookingRepo.FindByID(ctx, waitlistedID)
+	assert.NoError(t, err)
+	expired := time.Now().Add(-time.Minute)
+	booking.WaitlistUntil = &expired
+	svc.(*service).cleanupBookings(ctx)
+	assert.Equal(t, 1, uses())
+	assert.NoError(t, svc.CancelBooking(ctx, confirmedID))
+	assert.Equal(t, 1, uses())
+}
+
+func TestListBookingsIsRestrictedAndPagesByKeyset(t *testing.T) {
+	svc, confRepo, userRepo := setupService()
+	ctx := context.Background()
+
+	assert.NoError(t, confRepo.Create(ctx, &conference.Conference{
+		Name:           "TechConf",
+		StartTime:      time.Now().Add(24 * time.Hour).UTC(),
+		EndTime:        time.Now().Add(26 * time.Hour).UTC(),
+		AvailableSlots: 10,
+		OwnerID:        "owner",
+	}))
+	bookings := map[string]string{}
+	for _, id := range []string{"owner", "user1", "user2", "user3", "user9"} {
+		assert.NoError(t, userRepo.Create(ctx, &user.User{ID: id}))
+	}
+	for _, id := range []string{"user1", "user2", "user3"} {
+		bookingID, err := svc.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: id})
+		assert.NoError(t, err)
+		bookings[id] = bookingID
+	}
+
+	// Attendees see only their own bookings, the owner sees the conference's
+	page, err := svc.ListBookings(ctx, query.Query{ConferenceID: "TechConf"}, "user1")
+	assert.NoError(t, err)
+	assert.Len(t, page.Items, 1)
+	assert.Equal(t, bookings["user1"], page.Items[0].ID)
+	_, err = svc.ListBookings(ctx, query.Query{UserID: "user2"}, "user1")
+	assert.ErrorIs(t, err, apperrors.ErrForbidden)
+	_, err = svc.ListBookings(ctx, query.Query{ConferenceID: "TechConf"}, "")
+	assert.ErrorIs(t, err, apperrors.ErrForbidden)
+
+	// Moving the last listed booking to the end does not skip the rest
+	page, err = svc.ListBookings(ctx, query.Query{ConferenceID: "TechConf", Sort: "user_id", Limit: 1}, "owner")
+	assert.NoError(t, err)
+	assert.Equal(t, bookings["user1"], page.Items[0].ID)
+	_, err = svc.TransferBooking(ctx, bookings["user1"], TransferBookingRequest{ToUserID: "user9"}, "user1")
+	assert.NoError(t, err)
+	page, err = svc.ListBookings(ctx, query.Query{ConferenceID: "TechConf", Sort: "user_id", Limit: 2, Cursor: page.NextCursor}, "owner")
+	assert.NoError(t, err)
+	assert.Len(t, page.Items, 2)
+	assert.Equal(t, bookings["user2"], page.Items[0].ID)
+	assert.Equal(t, bookings["user3"], page.Items[1].ID)
+}
+
+func TestTransferBookingMovesSeatAndNotifiesBothUsers(t *testing.T) {
+	service, confRepo, userRepo, notifier := setupServiceWithNotifier()
+	ctx := context.Background()
+
+	assert.NoError(t, confRepo.Create(ctx, &conference.Conference{
+		Name:           "TechConf",
+		StartTime:      time.Now().Add(24 * time.Hour).UTC(),
+		EndTime:        time.Now().Add(26 * time.Hour).UTC(),
+		AvailableSlots: 10,
+	}))
+	for _, id := range []string{"alice", "bob", "carol"} {
+		assert.NoError(t, userRepo.Create(ctx, &user.User{ID: id}))
+	}
+	bookingID, err := service.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: "alice"})
+	assert.NoError(t, err)
+	_, err = service.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: "carol"})
+	assert.NoError(t, err)
+
+	// Only the holder transfers, and not to someone already booked
+	_, err = service.TransferBooking(ctx, bookingID, TransferBookingRequest{ToUserID: "bob"}, "bob")
+	assert.ErrorIs(t, err, apperrors.ErrForbidden)
+	_, err = service.TransferBooking(ctx, bookingID, TransferBookingRequest{ToUserID: "carol"}, "alice")
+	assert.Error(t, err)
+
+	transferred, err := service.TransferBooking(ctx, bookingID, TransferBookingRequest{ToUserID: "bob"}, "alice")
+	assert.NoError(t, err)
+	assert.Equal(t, "bob", transferred.UserID)
+	assert.Equal(t, "Confirmed", transferred.Status)
+	assert.Len(t, transferred.Transfers, 1)
+	assert.Equal(t, "alice", transferred.Transfers[0].FromUserID)
+
+	// The seat count is untouched and both parties hear about it
+	conf, err := confRepo.FindByName(ctx, "TechConf")
+	assert.NoError(t, err)
+	assert.Equal(t, 8, conf.AvailableSlots)
+	assert.Len(t, notifier.sent, 2)
+	assert.Equal(t, "alice", notifier.sent[0].UserID)
+	assert.Equal(t, "bob", notifier.sent[1].UserID)
+}
+
+func TestTransfersCannotGiveOverlappingSeats(t *testing.T) {
+	service, confRepo, userRepo := setupService()
+	ctx := context.Background()
+
+	start := time.Now().Add(24 * time.Hour).UTC()
+	for _, name := range []string{"TechConf", "DevConf"} {
+		assert.NoError(t, confRepo.Create(ctx, &conference.Conference{
+			Name:           name,
+			StartTime:      start,
+			EndTime:        start.Add(2 * time.Hour),
+			AvailableSlots: 10,
+		}))
+	}
+	for _, id := range []string{"alice", "bob", "carol"} {
+		assert.NoError(t, userRepo.Create(ctx, &user.User{ID: id}))
+	}
+	techID, err := service.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: "alice"})
+	assert.NoError(t, err)
+	devID, err := service.BookConference(ctx, BookConferenceRequest{ConferenceName: "DevConf", UserID: "carol"})
+	assert.NoError(t, err)
+
+	// Both holders hand their seat to bob at once; only one of the overlapping seats reaches him
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i, transfer := range []struct{ bookingID, holder string }{{techID, "alice"}, {devID, "carol"}} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, errs[i] = service.TransferBooking(ctx, transfer.bookingID, TransferBookingRequest{ToUserID: "bob"}, transfer.holder)
+		}()
+	}
+	wg.Wait()
+
+	var overlap *OverlapError
+	if errs[0] == nil {
+		assert.ErrorAs(t, errs[1], &overlap)
+	} else {
+		assert.ErrorAs(t, errs[0], &overlap)
+		assert.NoError(t, errs[1])
+	}
+	page, err := service.ListBookings(ctx, query.Query{Limit: 10}, "bob")
+	assert.NoError(t, err)
+	assert.Len(t, page.Items, 1)
+}
+
+func TestUserCalendarNeedsTheFeedToken(t *testing.T) {
+	svc, confRepo, userRepo := setupService()
+	ctx := context.Background()
+
+	start := time.Now().Add(24 * time.Hour).UTC()
+	for i, name := range []string{"TechConf", "DevConf", "OpsConf"} {
+		assert.NoError(t, confRepo.Create(ctx, &conference.Conference{
+			Name:           name,
+			StartTime:      start.Add(time.Duration(i) * 24 * time.Hour),
+			EndTime:        start.Add(time.Duration(i)*24*time.Hour + 2*time.Hour),
+			AvailableSlots: 10,
+		}))
+	}
+	assert.NoError(t, userRepo.Create(ctx, &user.User{ID: "alice", CalendarToken: "secret"}))
+	assert.NoError(t, userRepo.Create(ctx, &user.User{ID: "bob"}))
+	techID, err := svc.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: "alice"})
+	assert.NoError(t, err)
+	devID, err := svc.BookConference(ctx, BookConferenceRequest{ConferenceName: "DevConf", UserID: "alice"})
+	assert.NoError(t, err)
+	assert.NoError(t, svc.CancelBooking(ctx, devID))
+	opsID, err := svc.BookConference(ctx, BookConferenceRequest{ConferenceName: "OpsConf", UserID: "alice"})
+	assert.NoError(t, err)
+	assert.NoError(t, svc.(*service).bookingRepo.CheckIn(ctx, opsID, time.Now().UTC()))
+
+	// A wrong or missing token, or a user without one, gets nothing
+	for _, feed := range []struct{ userID, token string }{{"alice", "guess"}, {"alice", ""}, {"bob", ""}} {
+		_, err = svc.GetUserCalendar(ctx, feed.userID, feed.token)
+		assert.ErrorIs(t, err, apperrors.ErrForbidden)
+	}
+	_, err = svc.GetUserCalendar(ctx, "nobody", "secret")
+	assert.ErrorIs(t, err, apperrors.ErrNotFound)
+
+	// The feed lists the confirmed and attended bookings, not the cancelled one
+	entries, err := svc.GetUserCalendar(ctx, "alice", "secret")
+	assert.NoError(t, err)
+	var ids []string
+	for _, entry := range entries {
+		ids = append(ids, entry.BookingID)
+	}
+	assert.ElementsMatch(t, []string{techID, opsID}, ids)
+}
+
+func TestCheckInWithSignedToken(t *testing.T) {
+	service, confRepo, userRepo := setupService()
+	ctx := context.Background()
+
+	assert.NoError(t, confRepo.Create(ctx, &conference.Conference{
+		Name:           "TechConf",
+		StartTime:      time.Now().Add(30 * time.Minute).UTC(),
+		EndTime:        time.Now().Add(3 * time.Hour).UTC(),
+		AvailableSlots: 10,
+		OwnerID:        "owner",
+	}))
+	for _, id := range []string{"owner", "user1", "user2"} {
+		assert.NoError(t, userRepo.Create(ctx, &user.User{ID: id}))
+	}
+	bookingID, err := service.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: "user1"})
+	assert.NoError(t, err)
+	_, err = service.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: "user2"})
+	assert.NoError(t, err)
+
+	// Only the holder gets the token
+	_, err = service.GetCheckInToken(ctx, bookingID, "user2")
+	assert.ErrorIs(t, err, apperrors.ErrForbidden)
+	token, err := service.GetCheckInToken(ctx, bookingID, "user1")
+	assert.NoError(t, err)
+
+	// Tampered tokens and scans by anyone but the owner are rejected
+	_, err = service.CheckIn(ctx, CheckInRequest{Token: token + "x"}, "owner")
+	assert.ErrorIs(t, err, checkin.ErrInvalidToken)
+	_, err = service.CheckIn(ctx, CheckInRequest{Token: token}, "user2")
+	assert.ErrorIs(t, err, apperrors.ErrForbidden)
+
+	// The first scan checks in, the second is a duplicate
+	booking, err := service.CheckIn(ctx, CheckInRequest{Token: token}, "owner")
+	assert.NoError(t, err)
+	assert.Equal(t, "Attended", booking.Status)
+	assert.NotNil(t, booking.CheckedInAt)
+	_, err = service.CheckIn(ctx, CheckInRequest{Token: token}, "owner")
+	assert.ErrorIs(t, err, ErrAlreadyCheckedIn)
+
+	attendance, err := service.GetAttendance(ctx, "TechConf", "owner")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, attendance.Booked)
+	assert.Equal(t, 1, attendance.Attended)
+}
+
+func TestNoShowsAreCountedAndBlockRepeatOffenders(t *testing.T) {
+	svc, confRepo, userRepo, _ := setupServiceWithConfig(Config{NoShows: NoShowRules{BlockAfter: 1}})
+	ctx := context.Background()
+
+	assert.NoError(t, confRepo.Create(ctx, &conference.Conference{
+		Name:           "TechConf",
+		StartTime:      time.Now().Add(30 * time.Minute).UTC(),
+		EndTime:        time.Now().Add(3 * time.Hour).UTC(),
+		AvailableSlots: 10,
+		OwnerID:        "owner",
+	}))
+	assert.NoError(t, confRepo.Create(ctx, &conference.Conference{
+		Name:           "NextConf",
+		StartTime:      time.Now().Add(48 * time.Hour).UTC(),
+		EndTime:        time.Now().Add(50 * time.Hour).UTC(),
+		AvailableSlots: 10,
+	}))
+	for _, id := range []string{"owner", "user1", "user2"} {
+		assert.NoError(t, userRepo.Create(ctx, &user.User{ID: id}))
+	}
+	absentID, err := svc.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: "user1"})
+	assert.NoError(t, err)
+	attendedID, err := svc.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: "user2"})
+	assert.NoError(t, err)
+	token, err := svc.GetCheckInToken(ctx, attendedID, "user2")
+	assert.NoError(t, err)
+	_, err = svc.CheckIn(ctx, CheckInRequest{Token: token}, "owner")
+	assert.NoError(t, err)
+
+	// The conference ends; cleanup marks the absent user, twice over without double counting
+	conf, err := confRepo.FindByName(ctx, "TechConf")
+	assert.NoError(t, err)
+	conf.StartTime = time.Now().Add(-3 * time.Hour).UTC()
+	conf.EndTime = time.Now().Add(-time.Hour).UTC()
+	assert.NoError(t, confRepo.Update(ctx, conf))
+	svc.(*service).cleanupBookings(ctx)
+	svc.(*service).cleanupBookings(ctx)
+
+	status, err := svc.GetBookingStatus(ctx, absentID)
+	assert.NoError(t, err)
+	assert.Equal(t, "NoShow", status.Status)
+	status, err = svc.GetBookingStatus(ctx, attendedID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Attended", status.Status)
+
+	absent, err := userRepo.FindByID(ctx, "user1")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, absent.NoShows)
+	attended, err := userRepo.FindByID(ctx, "user2")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, attended.NoShows)
+
+	// The policy now blocks the repeat offender only
+	_, err = svc.BookConference(ctx, BookConferenceRequest{ConferenceName: "NextConf", UserID: "user1"})
+	assert.ErrorIs(t, err, ErrTooManyNoShows)
+	_, err = svc.BookConference(ctx, BookConferenceRequest{ConferenceName: "NextConf", UserID: "user2"})
+	assert.NoError(t, err)
+}
+
+func TestNoShowPolicyDeprioritizesWaitlist(t *testing.T) {
+	service, confRepo, userRepo, _ := setupServiceWithConfig(Config{NoShows: NoShowRules{DeprioritizeAfter: 2}})
+	ctx := context.Background()
+
+	assert.NoError(t, confRepo.Create(ctx, &conference.Conference{
+		Name:           "TechConf",
+		StartTime:      time.Now().Add(48 * time.Hour).UTC(),
+		EndTime:        time.Now().Add(50 * time.Hour).UTC(),
+		AvailableSlots: 1,
+	}))
+	assert.NoError(t, userRepo.Create(ctx, &user.User{ID: "user1"}))
+	assert.NoError(t, userRepo.Create(ctx, &user.User{ID: "user2", NoShows: 2}))
+	assert.NoError(t, userRepo.Create(ctx, &user.User{ID: "user3"}))
+
+	confirmedID, err := service.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: "user1"})
+	assert.NoError(t, err)
+	offenderID, err := service.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: "user2"})
+	assert.NoError(t, err)
+	laterID, err := service.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: "user3"})
+	assert.NoError(t, err)
+
+	// The freed seat skips the earlier waitlisted user with too many no-shows
+	assert.NoError(t, service.CancelBooking(ctx, confirmedID))
+	status, err := service.GetBookingStatus(ctx, laterID)
+	assert.NoError(t, err)
+	assert.Equal(t, "PendingConfirmation", status.Status)
+	status, err = service.GetBookingStatus(ctx, offenderID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Waitlisted", status.Status)
+	assert.ErrorIs(t, service.ConfirmWaitlistBooking(ctx, ConfirmWaitlistRequest{BookingID: offenderID}), ErrSlotUnavailable)
+	assert.NoError(t, service.ConfirmWaitlistBooking(ctx, ConfirmWaitlistRequest{BookingID: laterID}))
+	status, err = service.GetBookingStatus(ctx, laterID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Confirmed", status.Status)
+}
+
+func TestWaitlistPromotesByTierThenJoinTime(t *testing.T) {
+	service, confRepo, userRepo := setupService()
+	ctx := context.Background()
+
+	assert.NoError(t, confRepo.Create(ctx, &conference.Conference{
+		Name:           "TechConf",
+		StartTime:      time.Now().Add(48 * time.Hour).UTC(),
+		EndTime:        time.Now().Add(50 * time.Hour).UTC(),
+		AvailableSlots: 1,
+	}))
+	// The owner hands out codes that carry a tier
+	assert.NoError(t, confRepo.CreatePromoCode(ctx, &conference.PromoCode{ConferenceName: "TechConf", Code: "MEMBER", Tier: conference.TierMember}))
+	assert.NoError(t, confRepo.CreatePromoCode(ctx, &conference.PromoCode{ConferenceName: "TechConf", Code: "SPEAKER", Tier: conference.TierPriority}))
+	codes := map[string]string{"member1": "MEMBER", "member2": "MEMBER", "vip": "SPEAKER"}
+	for _, id := range []string{"holder", "standard", "member1", "member2", "vip"} {
+		assert.NoError(t, userRepo.Create(ctx, &user.User{ID: id}))
+	}
+
+	holderID, err := service.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: "holder"})
+	assert.NoError(t, err)
+	ids := make(map[string]string)
+	for _, id := range []string{"standard", "member1", "member2", "vip"} {
+		ids[id], err = service.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: id, Code: codes[id]})
+		assert.NoError(t, err)
+	}
+
+	// Positions follow (tier, join time)
+	for want, id := range []string{"vip", "member1", "member2", "standard"} {
+		position, err := service.GetWaitlistPosition(ctx, ids[id])
+		assert.NoError(t, err)
+		assert.Equal(t, want+1, position.Position, id)
+		assert.Equal(t, 4, position.Length)
+	}
+	position, err := service.GetWaitlistPosition(ctx, ids["member2"])
+	assert.NoError(t, err)
+	assert.Equal(t, conference.TierMember, position.Tier)
+	_, err = service.GetWaitlistPosition(ctx, holderID)
+	assert.ErrorIs(t, err, ErrNotWaitlisted)
+
+	// The freed seat goes to the priority user who joined last
+	assert.NoError(t, service.CancelBooking(ctx, holderID))
+	status, err := service.GetBookingStatus(ctx, ids["vip"])
+	assert.NoError(t, err)
+	assert.Equal(t, "PendingConfirmation", status.Status)
+	position, err = service.GetWaitlistPosition(ctx, ids["member1"])
+	assert.NoError(t, err)
+	assert.Equal(t, 1, position.Position)
+	assert.ErrorIs(t, service.ConfirmWaitlistBooking(ctx, ConfirmWaitlistRequest{BookingID: ids["member1"]}), ErrSlotUnavailable)
+	assert.NoError(t, service.ConfirmWaitlistBooking(ctx, ConfirmWaitlistRequest{BookingID: ids["vip"]}))
+	status, err = service.GetBookingStatus(ctx, ids["vip"])
+	assert.NoError(t, err)
+	assert.Equal(t, "Confirmed", status.Status)
+}
+
+func TestWaitlistTierComesFromUserTicketTypeOrCode(t *testing.T) {
+	service, confRepo, userRepo := setupService()
+	ctx := context.Background()
+
+	assert.NoError(t, confRepo.Create(ctx, &conference.Conference{
+		Name:           "TechConf",
+		StartTime:      time.Now().Add(48 * time.Hour).UTC(),
+		EndTime:        time.Now().Add(50 * time.Hour).UTC(),
+		AvailableSlots: 5,
+	}))
+	assert.NoError(t, confRepo.CreateTicketType(ctx, &conference.TicketType{ConferenceName: "TechConf", Name: "Member", Capacity: 1, AvailableSlots: 1, Tier: conference.TierMember}))
+	assert.NoError(t, confRepo.CreatePromoCode(ctx, &conference.PromoCode{ConferenceName: "TechConf", Code: "GUEST", Tier: conference.TierStandard}))
+	assert.NoError(t, userRepo.Create(ctx, &user.User{ID: "speaker", Tier: conference.TierPriority}))
+	for _, id := range []string{"holder", "member", "guest"} {
+		assert.NoError(t, userRepo.Create(ctx, &user.User{ID: id}))
+	}
+
+	_, err := service.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: "holder", TicketType: "Member"})
+	assert.NoError(t, err)
+	ids := make(map[string]string)
+	for _, id := range []string{"member", "guest", "speaker"} {
+		code := ""
+		if id == "guest" {
+			code = "GUEST"
+		}
+		ids[id], err = service.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: id, TicketType: "Member", Code: code})
+		assert.NoError(t, err)
+	}
+
+	// The user's tier beats that of the ticket type, and a worse code tier does not lower it
+	for want, entry := range []struct{ id, tier string }{{"speaker", conference.TierPriority}, {"member", conference.TierMember}, {"guest", conference.TierMember}} {
+		position, err := service.GetWaitlistPosition(ctx, ids[entry.id])
+		assert.NoError(t, err)
+		assert.Equal(t, want+1, position.Position, entry.id)
+		assert.Equal(t, entry.tier, position.Tier, entry.id)
+	}
+}
+
+func TestLotteryDrawAllocatesSeatsAuditably(t *testing.T) {
+	svc, confRepo, userRepo := setupService()
+	ctx := context.Background()
+
+	assert.NoError(t, confRepo.Create(ctx, &conference.Conference{
+		Name:           "TechConf",
+		StartTime:      time.Now().Add(48 * time.Hour).UTC(),
+		EndTime:        time.Now().Add(50 * time.Hour).UTC(),
+		AvailableSlots: 2,
+		OwnerID:        "owner",
+		Lottery:        &conference.Lottery{RegistrationCloses: time.Now().Add(time.Hour).UTC()},
+	}))
+	users := []string{"user1", "user2", "user3", "user4", "user5"}
+	for _, id := range append([]string{"owner", "late"}, users...) {
+		assert.NoError(t, userRepo.Create(ctx, &user.User{ID: id}))
+	}
+
+	// Bookings during registration are entries holding no seat
+	for _, id := range users {
+		bookingID, err := svc.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: id})
+		assert.NoError(t, err)
+		status, err := svc.GetBookingStatus(ctx, bookingID)
+		assert.NoError(t, err)
+		assert.Equal(t, "LotteryEntry", status.Status)
+	}
+	_, err := svc.BookGroup(ctx, BookGroupRequest{ConferenceName: "TechConf", BookerID: "owner", Seats: 2})
+	assert.ErrorIs(t, err, ErrLotteryPending)
+
+	// Only the owner draws
+	seed := int64(42)
+	_, err = svc.DrawLottery(ctx, "TechConf", DrawRequest{Seed: &seed}, "user1")
+	assert.ErrorIs(t, err, apperrors.ErrForbidden)
+	draw, err := svc.DrawLottery(ctx, "TechConf", DrawRequest{Seed: &seed}, "owner")
+	assert.NoError(t, err)
+	assert.Equal(t, seed, draw.Seed)
+	assert.Len(t, draw.Entries, 5)
+	assert.Len(t, draw.Results, 5)
+
+	// Replaying the shuffle from the audit record reproduces the results
+	replay := append([]string(nil), draw.Entries...)
+	mathrand.New(mathrand.NewSource(draw.Seed)).Shuffle(len(replay), func(i, j int) { replay[i], replay[j] = replay[j], replay[i] })
+	for i, result := range draw.Results {
+		assert.Equal(t, replay[i], result.BookingID)
+		assert.Equal(t, i+1, result.Rank)
+		if i < 2 {
+			assert.Equal(t, "Confirmed", result.Status)
+		} else {
+			assert.Equal(t, "Waitlisted", result.Status)
+		}
+	}
+
+	// The waitlist keeps the drawn order
+	position, err := svc.GetWaitlistPosition(ctx, draw.Results[3].BookingID)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, position.Position)
+
+	// The lottery is drawn once, after which booking is first come, first served
+	_, err = svc.DrawLottery(ctx, "TechConf", DrawRequest{}, "owner")
+	assert.ErrorIs(t, err, ErrInvalidAction)
+	lateID, err := svc.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: "late"})
+	assert.NoError(t, err)
+	position, err = svc.GetWaitlistPosition(ctx, lateID)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, position.Position)
+
+	recorded, err := svc.GetDraw(ctx, "TechConf")
+	assert.NoError(t, err)
+	assert.Equal(t, draw, recorded)
+}
+
+func TestLotteryDrawRecordsFailedEntries(t *testing.T) {
+	svc, confRepo, userRepo := setupService()
+	ctx := context.Background()
+
+	assert.NoError(t, confRepo.Create(ctx, &conference.Conference{
+		Name:           "TechConf",
+		StartTime:      time.Now().Add(48 * time.Hour).UTC(),
+		EndTime:        time.Now().Add(50 * time.Hour).UTC(),
+		AvailableSlots: 3,
+		OwnerID:        "owner",
+		Lottery:        &conference.Lottery{RegistrationCloses: time.Now().Add(time.Hour).UTC()},
+	}))
+	var entries []*Booking
+	for _, id := range []string{"user1", "user2", "user3"} {
+		assert.NoError(t, userRepo.Create(ctx, &user.User{ID: id}))
+		bookingID, err := svc.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: id})
+		assert.NoError(t, err)
+		entry, err := svc.(*service).bookingRepo.FindByID(ctx, bookingID)
+		assert.NoError(t, err)
+		entries = append(entries, entry)
+	}
+	// The ticket type of one entry has gone, so it cannot be seated
+	entries[1].TicketType = "Retired"
+
+	seed := int64(7)
+	draw, err := svc.DrawLottery(ctx, "TechConf", DrawRequest{Seed: &seed}, "owner")
+	assert.Error(t, err)
+	recorded, err := svc.GetDraw(ctx, "TechConf")
+	assert.NoError(t, err)
+
+	// Every drawn entry is recorded in drawn order, the failed one with its reason
+	assert.Nil(t, draw)
+	replay := append([]string(nil), recorded.Entries...)
+	mathrand.New(mathrand.NewSource(recorded.Seed)).Shuffle(len(replay), func(i, j int) { replay[i], replay[j] = replay[j], replay[i] })
+	assert.Len(t, recorded.Results, 3)
+	for i, result := range recorded.Results {
+		assert.Equal(t, replay[i], result.BookingID)
+		assert.Equal(t, i+1, result.Rank)
+		if result.BookingID == entries[1].ID {
+			assert.Equal(t, DrawFailed, result.Status)
+			assert.NotEmpty(t, result.Error)
+		} else {
+			assert.Equal(t, "Confirmed", result.Status)
+			assert.Empty(t, result.Error)
+		}
+	}
+}
+
+func TestLotteryDrawIsClaimedOnceAndChecksWinners(t *testing.T) {
+	svc, confRepo, userRepo, _ := setupServiceWithConfig(Config{NoShows: NoShowRules{BlockAfter: 1}})
+	ctx := context.Background()
+
+	start := time.Now().Add(48 * time.Hour).UTC()
+	assert.NoError(t, confRepo.Create(ctx, &conference.Conference{
+		Name:           "TechConf",
+		StartTime:      start,
+		EndTime:        start.Add(2 * time.Hour),
+		AvailableSlots: 3,
+		OwnerID:        "owner",
+		Lottery:        &conference.Lottery{RegistrationCloses: time.Now().Add(time.Hour).UTC()},
+	}))
+	assert.NoError(t, confRepo.Create(ctx, &conference.Conference{
+		Name:           "OtherConf",
+		StartTime:      start.Add(time.Hour),
+		EndTime:        start.Add(3 * time.Hour),
+		AvailableSlots: 10,
+	}))
+	entries := map[string]string{}
+	for _, id := range []string{"blocked", "busy", "free"} {
+		assert.NoError(t, userRepo.Create(ctx, &user.User{ID: id}))
+		bookingID, err := svc.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: id})
+		assert.NoError(t, err)
+		entries[id] = bookingID
+	}
+
+	// After entering, one user books an overlapping conference and another is blocked for no-shows
+	_, err := svc.BookConference(ctx, BookConferenceRequest{ConferenceName: "OtherConf", UserID: "busy"})
+	assert.NoError(t, err)
+	blocked, err := userRepo.FindByID(ctx, "blocked")
+	assert.NoError(t, err)
+	blocked.NoShows = 1
+
+	// The owner and the scheduler race; only one of them draws
+	conf, err := confRepo.FindByName(ctx, "TechConf")
+	assert.NoError(t, err)
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = svc.(*service).draw(ctx, conf, 42, "owner")
+		}(i)
+	}
+	wg.Wait()
+	if errs[0] == nil {
+		assert.ErrorIs(t, errs[1], ErrInvalidAction)
+	} else {
+		assert.ErrorIs(t, errs[0], ErrInvalidAction)
+		assert.NoError(t, errs[1])
+	}
+
+	for id, want := range map[string]string{"blocked": "Canceled", "busy": "Waitlisted", "free": "Confirmed"} {
+		status, err := svc.GetBookingStatus(ctx, entries[id])
+		assert.NoError(t, err)
+		assert.Equal(t, want, status.Status, id)
+	}
+}
+
+func TestSchedulerDrawsLotteryAfterRegistrationCloses(t *testing.T) {
+	svc, confRepo, userRepo := setupService()
+	ctx := context.Background()
+
+	assert.NoError(t, confRepo.Create(ctx, &conference.Conference{
+		Name:           "TechConf",
+		StartTime:      time.Now().Add(48 * time.Hour).UTC(),
+		EndTime:        time.Now().Add(50 * time.Hour).UTC(),
+		AvailableSlots: 1,
+		Lottery:        &conference.Lottery{RegistrationCloses: time.Now().Add(time.Hour).UTC()},
+	}))
+	assert.NoError(t, userRepo.Create(ctx, &user.User{ID: "user1"}))
+	bookingID, err := svc.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: "user1"})
+	assert.NoError(t, err)
+
+	// Nothing is drawn while registration is open
+	svc.(*service).cleanupBookings(ctx)
+	_, err = svc.GetDraw(ctx, "TechConf")
+	assert.ErrorIs(t, err, apperrors.ErrNotFound)
+
+	conf, err := confRepo.FindByName(ctx, "TechConf")
+	assert.NoError(t, err)
+	conf.Lottery.RegistrationCloses = time.Now().Add(-time.Minute).UTC()
+	assert.NoError(t, confRepo.Update(ctx, conf))
+	svc.(*service).cleanupBookings(ctx)
+
+	draw, err := svc.GetDraw(ctx, "TechConf")
+	assert.NoError(t, err)
+	assert.Equal(t, "scheduler", draw.DrawnBy)
+	status, err := svc.GetBookingStatus(ctx, bookingID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Confirmed", status.Status)
+}
+
+func TestSeatHoldsCommitOrExpire(t *testing.T) {
+	svc, confRepo, userRepo := setupService()
+	ctx := context.Background()
+
+	assert.NoError(t, confRepo.Create(ctx, &conference.Conference{
+		Name:           "TechConf",
+		StartTime:      time.Now().Add(48 * time.Hour).UTC(),
+		EndTime:        time.Now().Add(50 * time.Hour).UTC(),
+		AvailableSlots: 1,
+	}))
+	for _, id := range []string{"user1", "user2", "user3"} {
+		assert.NoError(t, userRepo.Create(ctx, &user.User{ID: id}))
+	}
+
+	// A hold takes the only seat, so others are waitlisted and cannot hold
+	hold, err := svc.HoldSeat(ctx, HoldSeatRequest{ConferenceName: "TechConf", UserID: "user1"})
+	assert.NoError(t, err)
+	assert.Equal(t, HoldActive, hold.Status)
+	conf, err := confRepo.FindByName(ctx, "TechConf")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, conf.AvailableSlots)
+	_, err = svc.HoldSeat(ctx, HoldSeatRequest{ConferenceName: "TechConf", UserID: "user2"})
+	assert.ErrorIs(t, err, ErrSlotUnavailable)
+	waitlistedID, err := svc.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: "user2"})
+	assert.NoError(t, err)
+
+	// Only the holder commits, once
+	_, err = svc.CommitHold(ctx, hold.ID, CommitHoldRequest{}, "user2")
+	assert.ErrorIs(t, err, apperrors.ErrForbidden)
+	booking, err := svc.CommitHold(ctx, hold.ID, CommitHoldRequest{}, "user1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Confirmed", booking.Status)
+	_, err = svc.CommitHold(ctx, hold.ID, CommitHoldRequest{}, "user1")
+	assert.ErrorIs(t, err, ErrInvalidAction)
+	conf, err = confRepo.FindByName(ctx, "TechConf")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, conf.AvailableSlots)
+
+	// The freed seat is kept for the waitlisted user, who confirms it
+	assert.NoError(t, svc.CancelBooking(ctx, booking.ID))
+	status, err := svc.GetBookingStatus(ctx, waitlistedID)
+	assert.NoError(t, err)
+	assert.Equal(t, "PendingConfirmation", status.Status)
+	_, err = svc.HoldSeat(ctx, HoldSeatRequest{ConferenceName: "TechConf", UserID: "user3"})
+	assert.ErrorIs(t, err, ErrSlotUnavailable)
+	assert.NoError(t, svc.ConfirmWaitlistBooking(ctx, ConfirmWaitlistRequest{BookingID: waitlistedID}))
+
+	// Without a waitlist the freed seat can be held again; an expired hold is released by the cleanup worker
+	assert.NoError(t, svc.CancelBooking(ctx, waitlistedID))
+	hold, err = svc.HoldSeat(ctx, HoldSeatRequest{ConferenceName: "TechConf", UserID: "user3"})
+	assert.NoError(t, err)
+	hold.ExpiresAt = time.Now().Add(-time.Second)
+	svc.(*service).cleanupBookings(ctx)
+
+	_, err = svc.CommitHold(ctx, hold.ID, CommitHoldRequest{}, "user3")
+	assert.ErrorIs(t, err, ErrInvalidAction)
+	conf, err = confRepo.FindByName(ctx, "TechConf")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, conf.AvailableSlots)
+
+	// ...or as soon as someone else needs the seat
+	hold, err = svc.HoldSeat(ctx, HoldSeatRequest{ConferenceName: "TechConf", UserID: "user3"})
+	assert.NoError(t, err)
+	hold.ExpiresAt = time.Now().Add(-time.Second)
+	bookingID, err := svc.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: "user2"})
+	assert.NoError(t, err)
+	status, err = svc.GetBookingStatus(ctx, bookingID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Confirmed", status.Status)
+	_, err = svc.CommitHold(ctx, hold.ID, CommitHoldRequest{}, "user3")
+	assert.ErrorIs(t, err, ErrInvalidAction)
+
+	// A hold committed twice at once books one seat
+	assert.NoError(t, svc.CancelBooking(ctx, bookingID))
+	hold, err = svc.HoldSeat(ctx, HoldSeatRequest{ConferenceName: "TechConf", UserID: "user3"})
+	assert.NoError(t, err)
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = svc.CommitHold(ctx, hold.ID, CommitHoldRequest{}, "user3")
+		}(i)
+	}
+	wg.Wait()
+	if errs[0] == nil {
+		assert.ErrorIs(t, errs[1], ErrInvalidAction)
+	} else {
+		assert.ErrorIs(t, errs[0], ErrInvalidAction)
+		assert.NoError(t, errs[1])
+	}
+	page, err := svc.ListBookings(ctx, query.Query{Status: "Confirmed"}, "user3")
+	assert.NoError(t, err)
+	assert.Len(t, page.Items, 1)
+}
+
+func TestSeatMapAssignsSeats(t *testing.T) {
+	svc, confRepo, userRepo := setupService()
+	ctx := context.Background()
+
+	assert.NoError(t, confRepo.Create(ctx, &conference.Conference{
+		Name:           "TechConf",
+		StartTime:      time.Now().Add(48 * time.Hour).UTC(),
+		EndTime:        time.Now().Add(50 * time.Hour).UTC(),
+		AvailableSlots: 3,
+		SeatMap: &conference.SeatMap{Rows: []conference.SeatRow{
+			{Label: "A", Seats: []conference.Seat{{Label: "A1"}, {Label: "A2", Accessible: true}, {Label: "A3"}}},
+			{Label: "B", Seats: []conference.Seat{{Label: "B1"}}},
+		}},
+	}))
+	for _, id := range []string{"user1", "user2", "user3", "user4", "user5"} {
+		assert.NoError(t, userRepo.Create(ctx, &user.User{ID: id}))
+	}
+
+	// A chosen seat is taken once; unknown seats are rejected
+	firstID, err := svc.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: "user1", Seat: "A3"})
+	assert.NoError(t, err)
+	_, err = svc.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: "user2", Seat: "A3"})
+	assert.ErrorIs(t, err, ErrSeatTaken)
+	_, err = svc.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: "user2", Seat: "Z9"})
+	assert.ErrorIs(t, err, apperrors.ErrInvalidInput)
+
+	// Auto-assignment keeps the accessible seat for those who ask for it
+	secondID, err := svc.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: "user2"})
+	assert.NoError(t, err)
+	thirdID, err := svc.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: "user3", Accessible: true})
+	assert.NoError(t, err)
+	for id, seat := range map[string]string{firstID: "A3", secondID: "A1", thirdID: "A2"} {
+		status, err := svc.GetBookingStatus(ctx, id)
+		assert.NoError(t, err)
+		assert.Equal(t, seat, status.Seat)
+	}
+	waitlistedID, err := svc.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: "user4"})
+	assert.NoError(t, err)
+	status, err := svc.GetBookingStatus(ctx, waitlistedID)
+	assert.NoError(t, err)
+	assert.Empty(t, status.Seat)
+
+	availability, err := svc.GetSeatAvailability(ctx, "TechConf", "")
+	assert.NoError(t, err)
+	assert.Equal(t, 4, availability.Total)
+	assert.Equal(t, 1, availability.Available)
+	assert.True(t, availability.Rows[1].Seats[0].Available)
+
+	// A cancelled seat is kept for the first waitlisted user, who gets it on confirming
+	assert.NoError(t, svc.CancelBooking(ctx, firstID))
+	availability, err = svc.GetSeatAvailability(ctx, "TechConf", "")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, availability.Available)
+	assert.NoError(t, svc.ConfirmWaitlistBooking(ctx, ConfirmWaitlistRequest{BookingID: waitlistedID}))
+	status, err = svc.GetBookingStatus(ctx, waitlistedID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Confirmed", status.Status)
+	assert.Equal(t, "A3", status.Seat)
+
+	// Without a waitlist a cancelled seat returns to the pool and is handed out again in row order
+	assert.NoError(t, svc.CancelBooking(ctx, secondID))
+	availability, err = svc.GetSeatAvailability(ctx, "TechConf", "")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, availability.Available)
+	nextID, err := svc.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: "user5"})
+	assert.NoError(t, err)
+	status, err = svc.GetBookingStatus(ctx, nextID)
+	assert.NoError(t, err)
+	assert.Equal(t, "A1", status.Seat)
+}
+
+func TestBookSeriesBooksEveryFutureOccurrence(t *testing.T) {
+	svc, confRepo, userRepo := setupService()
+	ctx := context.Background()
+
+	assert.NoError(t, confRepo.CreateSeries(ctx, &conference.Series{ID: "meetup", Name: "GoMeetup"}, nil))
+	occurrence := func(name string, start time.Time) *conference.Conference {
+		return &conference.Conference{Name: name, StartTime: start, EndTime: start.Add(2 * time.Hour), AvailableSlots: 1, SeriesID: "meetup"}
+	}
+	now := time.Now().UTC()
+	assert.NoError(t, confRepo.Create(ctx, occurrence("GoMeetup-past", now.Add(-7*24*time.Hour))))
+	assert.NoError(t, confRepo.Create(ctx, occurrence("GoMeetup-1", now.Add(24*time.Hour))))
+	assert.NoError(t, confRepo.Create(ctx, occurrence("GoMeetup-2", now.Add(8*24*time.Hour))))
+	assert.NoError(t, confRepo.Create(ctx, &conference.Conference{Name: "DevConf", StartTime: now.Add(8 * 24 * time.Hour), EndTime: now.Add(8*24*time.Hour + time.Hour), AvailableSlots: 10}))
+	for _, id := range []string{"user1", "user2"} {
+		assert.NoError(t, userRepo.Create(ctx, &user.User{ID: id}))
+	}
+
+	// An overlap in one occurrence fails the whole series booking
+	devConfID, err := svc.BookConference(ctx, BookConferenceRequest{ConferenceName: "DevConf", UserID: "user1"})
+	assert.NoError(t, err)
+	_, err = svc.BookSeries(ctx, BookSeriesRequest{SeriesID: "meetup", UserID: "user1"})
+	assert.ErrorIs(t, err, ErrBookingConflict)
+	conf, err := confRepo.FindByName(ctx, "GoMeetup-1")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, conf.AvailableSlots)
+
+	// Without it, every future occurrence is booked; full ones waitlist
+	assert.NoError(t, svc.CancelBooking(ctx, devConfID))
+	_, err = svc.BookConference(ctx, BookConferenceRequest{ConferenceName: "GoMeetup-2", UserID: "user2"})
+	assert.NoError(t, err)
+	seriesBooking, err := svc.BookSeries(ctx, BookSeriesRequest{SeriesID: "meetup", UserID: "user1"})
+	assert.NoError(t, err)
+	assert.Len(t, seriesBooking.Occurrences, 2)
+	status, err := svc.GetBookingStatus(ctx, seriesBooking.Occurrences["GoMeetup-1"])
+	assert.NoError(t, err)
+	assert.Equal(t, "Confirmed", status.Status)
+	status, err = svc.GetBookingStatus(ctx, seriesBooking.Occurrences["GoMeetup-2"])
+	assert.NoError(t, err)
+	assert.Equal(t, "Waitlisted", status.Status)
+	_, err = svc.BookSeries(ctx, BookSeriesRequest{SeriesID: "meetup", UserID: "user1"})
+	assert.Error(t, err)
+
+	// Occurrences generated later are booked by the cleanup worker
+	assert.NoError(t, confRepo.Create(ctx, occurrence("GoMeetup-3", now.Add(15*24*time.Hour))))
+	svc.(*service).cleanupBookings(ctx)
+	seriesBooking, err = svc.GetSeriesBooking(ctx, seriesBooking.ID)
+	assert.NoError(t, err)
+	assert.Len(t, seriesBooking.Occurrences, 3)
+	booking, err := svc.(*service).bookingRepo.FindByID(ctx, seriesBooking.Occurrences["GoMeetup-3"])
+	assert.NoError(t, err)
+	assert.Equal(t, seriesBooking.ID, booking.SeriesBookingID)
+
+	// Only the booked user stops the series booking, which cancels every future occurrence and
+	// books no new ones
+	_, err = svc.CancelSeriesBooking(ctx, seriesBooking.ID, "user2")
+	assert.ErrorIs(t, err, apperrors.ErrForbidden)
+	cancelled, err := svc.CancelSeriesBooking(ctx, seriesBooking.ID, "user1")
+	assert.NoError(t, err)
+	assert.NotNil(t, cancelled.CancelledAt)
+	for _, bookingID := range cancelled.Occurrences {
+		status, err := svc.GetBookingStatus(ctx, bookingID)
+		assert.NoError(t, err)
+		assert.Equal(t, "Canceled", status.Status)
+	}
+	assert.NoError(t, confRepo.Create(ctx, occurrence("GoMeetup-4", now.Add(22*24*time.Hour))))
+	svc.(*service).cleanupBookings(ctx)
+	seriesBooking, err = svc.GetSeriesBooking(ctx, seriesBooking.ID)
+	assert.NoError(t, err)
+	assert.Len(t, seriesBooking.Occurrences, 3)
+	_, err = svc.CancelSeriesBooking(ctx, seriesBooking.ID, "user1")
+	assert.NoError(t, err)
+}
+
+func TestConferenceLifecycleGatesBookingAndCancellation(t *testing.T) {
+	confRepo := conference.NewInMemoryRepository()
+	userRepo := user.NewInMemoryRepository()
+	payments := &racingProvider{Provider: payment.NewFakeProvider()}
+	notifier := &recordingNotifier{}
+	signer, _ := checkin.NewSigner("test-secret")
+	service := NewService(confRepo, userRepo, NewInMemoryRepository(confRepo), payments, notifier, signer, Config{})
+	ctx := context.Background()
+
+	conf := &conference.Conference{
+		Name:           "TechConf",
+		StartTime:      time.Now().Add(24 * time.Hour).UTC(),
+		EndTime:        time.Now().Add(26 * time.Hour).UTC(),
+		AvailableSlots: 1,
+		OwnerID:        "organiser",
+		Status:         conference.StatusDraft,
+	}
+	assert.NoError(t, confRepo.Create(ctx, conf))
+	assert.NoError(t, confRepo.CreateTicketType(ctx, &conference.TicketType{ConferenceName: "TechConf", Name: "Regular", Capacity: 1, AvailableSlots: 1, Price: 4900, Currency: "EUR"}))
+	assert.NoError(t, confRepo.CreatePromoCode(ctx, &conference.PromoCode{ConferenceName: "TechConf", Code: "FRIENDS", MaxUses: 1}))
+	for _, id := range []string{"user1", "user2"} {
+		assert.NoError(t, userRepo.Create(ctx, &user.User{ID: id}))
+	}
+
+	// Drafts and closed registrations take no bookings, and only the owner sees a draft's bookings
+	_, err := service.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: "user1", TicketType: "Regular"})
+	assert.ErrorIs(t, err, apperrors.ErrNotFound)
+	_, err = service.ListBookings(ctx, query.Query{ConferenceID: "TechConf"}, "user1")
+	assert.ErrorIs(t, err, apperrors.ErrNotFound)
+	_, err = service.ListBookings(ctx, query.Query{ConferenceID: "TechConf"}, "organiser")
+	assert.NoError(t, err)
+	conf.Status = conference.StatusRegistrationClosed
+	_, err = service.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: "user1", TicketType: "Regular"})
+	assert.ErrorIs(t, err, ErrRegistrationClosed)
+
+	// Once published, one user pays for the seat and the other is waitlisted
+	conf.Status = conference.StatusPublished
+	paidID, err := service.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: "user1", TicketType: "Regular"})
+	assert.NoError(t, err)
+	status, err := service.GetBookingStatus(ctx, paidID)
+	assert.NoError(t, err)
+	_, err = service.PayOrder(ctx, status.OrderID, PayOrderRequest{Token: "tok_visa"}, "user1")
+	assert.NoError(t, err)
+	waitlistedID, err := service.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: "user2", TicketType: "Regular", Code: "FRIENDS"})
+	assert.NoError(t, err)
+
+	// A failed refund leaves its booking alone but cancels the rest
+	_, err = service.CancelConference(ctx, "TechConf", "user1")
+	assert.ErrorIs(t, err, apperrors.ErrForbidden)
+	payments.refundErr = errors.New("provider unavailable")
+	_, err = service.CancelConference(ctx, "TechConf", "organiser")
+	assert.Error(t, err)
+	assert.Equal(t, conference.StatusCancelled, conf.Status)
+	status, err = service.GetBookingStatus(ctx, paidID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Confirmed", status.Status)
+	status, err = service.GetBookingStatus(ctx, waitlistedID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Canceled", status.Status)
+
+	// Cancelling again retries it, refunds in full and tells every user once
+	payments.refundErr = nil
+	cancelled, err := service.CancelConference(ctx, "TechConf", "organiser")
+	assert.NoError(t, err)
+	assert.Equal(t, conference.StatusCancelled, cancelled.Status)
+
+	status, err = service.GetBookingStatus(ctx, paidID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Canceled", status.Status)
+	assert.Equal(t, 100, status.Refund.Percent)
+	assert.Equal(t, int64(4900), status.Refund.Amount)
+	status, err = service.GetBookingStatus(ctx, waitlistedID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Canceled", status.Status)
+
+	// Bookings are released as cancelled bookings are: the waitlisted one gives its code use back,
+	// and the freed seat returns to its pool instead of being offered to anyone
+	code, err := confRepo.FindPromoCode(ctx, "TechConf", "FRIENDS")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, code.Uses)
+	ticketType, err := confRepo.FindTicketType(ctx, "TechConf", "Regular")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, ticketType.AvailableSlots)
+
+	var notified []string
+	for _, n := range notifier.sent {
+		assert.NotEqual(t, "Seat available", n.Subject)
+		if n.Subject == "Conference cancelled" {
+			notified = append(notified, n.UserID)
+		}
+	}
+	assert.ElementsMatch(t, []string{"user1", "user2"}, notified)
+
+	_, err = service.BookConference(ctx, BookConferenceRequest{ConferenceName: "TechConf", UserID: "user1", TicketType: "Regular"})
+	assert.ErrorIs(t, err, ErrRegistrationClosed)
+	_, err = service.CancelConference(ctx, "TechConf", "organiser")
+	assert.NoError(t, err)
+}
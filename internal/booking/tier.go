@@ -0,0 +1,107 @@
+package booking
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"conference-booking/internal/conference"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tierDeprioritized ranks users deprioritized by the no-show policy below every user tier.
+const tierDeprioritized = "deprioritized"
+
+// tierRanks orders waitlist tiers, lowest first.
+var tierRanks = map[string]int{
+	conference.TierPriority: 0,
+	conference.TierMember:   1,
+	conference.TierStandard: 2,
+	tierDeprioritized:       3,
+}
+
+var ErrNotWaitlisted = errors.New("booking is not waitlisted")
+
+// tier returns the waitlist tier of a booking: the best of the tiers of its attendee (or, for
+// unassigned group seats, its booker), of its ticket type and of the code it was made with, unless
+// that user is deprioritized by the no-show policy.
+func (s *service) tier(ctx context.Context, booking *Booking) string {
+	userID := booking.UserID
+	if userID == "" {
+		userID = booking.BookerID
+	}
+	tier := conference.TierStandard
+	better := func(candidate string) {
+		if candidate != "" && tierRanks[candidate] < tierRanks[tier] {
+			tier = candidate
+		}
+	}
+	if u, err := s.userRepo.FindByID(ctx, userID); err == nil {
+		if s.cfg.NoShows.deprioritizes(u) {
+			return tierDeprioritized
+		}
+		better(u.Tier)
+	}
+	if booking.TicketType != "" {
+		if ticketType, err := s.confRepo.FindTicketType(ctx, booking.ConferenceID, booking.TicketType); err == nil {
+			better(ticketType.Tier)
+		}
+	}
+	if booking.Code != "" {
+		if code, err := s.confRepo.FindPromoCode(ctx, booking.ConferenceID, booking.Code); err == nil {
+			better(code.Tier)
+		}
+	}
+	return tier
+}
+
+// prioritize reorders a waitlist given in joining order by (tier, join time). Entries waitlisted
+// by a lottery draw count as joining in drawn order at the draw.
+func (s *service) prioritize(ctx context.Context, waitlist []*Booking) []*Booking {
+	ranks := make(map[string]int, len(waitlist))
+	for _, booking := range waitlist {
+		ranks[booking.ID] = tierRanks[s.tier(ctx, booking)]
+	}
+	sort.SliceStable(waitlist, func(i, j int) bool {
+		a, b := waitlist[i], waitlist[j]
+		if ranks[a.ID] != ranks[b.ID] {
+			return ranks[a.ID] < ranks[b.ID]
+		}
+		// Lottery losers keep their drawn order, ahead of anyone who joined after the draw
+		return a.LotteryRank != 0 && (b.LotteryRank == 0 || a.LotteryRank < b.LotteryRank)
+	})
+	return waitlist
+}
+
+// GetWaitlistPosition returns where a waitlisted booking stands in its pool's promotion order.
+func (s *service) GetWaitlistPosition(ctx context.Context, bookingID string) (*WaitlistPosition, error) {
+	ctx, span := tracer.Start(ctx, "booking.Service.GetWaitlistPosition", trace.WithAttributes(attribute.String("booking.id", bookingID)))
+	defer span.End()
+
+	booking, err := s.bookingRepo.FindByID(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if booking.Status != "Waitlisted" {
+		return nil, ErrNotWaitlisted
+	}
+	p, err := s.findPool(ctx, booking)
+	if err != nil {
+		return nil, err
+	}
+
+	waitlist := s.waitlist(ctx, p)
+	for i, entry := range waitlist {
+		if entry.ID == booking.ID {
+			return &WaitlistPosition{
+				BookingID: booking.ID,
+				Tier:      s.tier(ctx, booking),
+				Position:  i + 1,
+				Length:    len(waitlist),
+			}, nil
+		}
+	}
+	return nil, ErrNotWaitlisted
+}
@@ -1,16 +1,46 @@
 package conference
 
 import (
+	stderrors "errors"
+	"mime"
 	"net/http"
 
+	"conference-booking/internal/venue"
+	"conference-booking/pkg/auth"
+	"conference-booking/pkg/errors"
+	"conference-booking/pkg/ical"
+	"conference-booking/pkg/query"
+
 	"github.com/gin-gonic/gin"
 )
 
-func RegisterRoutes(router *gin.Engine, repo Repository) {
-	h := NewHandler(repo)
+func RegisterRoutes(router *gin.Engine, repo Repository, rooms venue.Repository, cfg Config) {
+	h := NewHandler(repo, rooms, cfg)
 	group := router.Group("/conference")
 	{
 		group.POST("", h.AddConference)
+		group.GET("", h.ListConferences)
+		group.GET("/:name/ics", h.GetCalendar)
+		group.POST("/:name/sessions", h.AddSession)
+		group.GET("/:name/sessions", h.ListSessions)
+		group.POST("/:name/tickets", h.AddTicketType)
+		group.GET("/:name/tickets", h.ListTicketTypes)
+		group.PUT("/:name/cancellation-policy", h.SetCancellationPolicy)
+		group.PUT("/:name/room", h.AssignRoom)
+		group.PUT("/:name/seat-map", h.SetSeatMap)
+		group.POST("/:name/publish", h.PublishConference)
+		group.POST("/:name/close-registration", h.CloseRegistration)
+		group.POST("/:name/codes", h.AddPromoCode)
+		group.GET("/:name/codes", h.ListPromoCodes)
+	}
+
+	series := router.Group("/series")
+	{
+		series.POST("", h.AddSeries)
+		series.GET("", h.ListSeries)
+		series.GET("/:id", h.GetSeries)
+		series.PATCH("/:id", h.UpdateSeries)
+		series.GET("/:id/occurrences", h.ListOccurrences)
 	}
 }
 
@@ -18,9 +48,9 @@ type Handler struct {
 	service Service
 }
 
-func NewHandler(repo Repository) *Handler {
+func NewHandler(repo Repository, rooms venue.Repository, cfg Config) *Handler {
 	return &Handler{
-		service: NewService(repo),
+		service: NewService(repo, rooms, cfg),
 	}
 }
 
@@ -31,10 +61,278 @@ func (h *Handler) AddConference(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.AddConference(req); err != nil {
-		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	// The creator becomes the owner of the conference
+	req.OwnerID = auth.UserID(c)
+
+	if err := h.service.AddConference(c.Request.Context(), req); err != nil {
+		c.JSON(errorStatus(err), errorBody(err))
 		return
 	}
 
 	c.JSON(http.StatusCreated, gin.H{"conference created": true})
 }
+
+func (h *Handler) ListConferences(c *gin.Context) {
+	q, err := query.FromRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The caller sees their own drafts
+	q.UserID = auth.UserID(c)
+
+	page, err := h.service.ListConferences(c.Request.Context(), q)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *Handler) GetCalendar(c *gin.Context) {
+	conf, err := h.service.GetConference(c.Request.Context(), c.Param("name"), auth.UserID(c))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Type", ical.ContentType)
+	c.Header("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": conf.Name + ".ics"}))
+	c.Status(http.StatusOK)
+	ical.Write(c.Writer, conf.Name, []ical.Event{{
+		UID:     "conference-" + conf.Name + "@conference-booking",
+		Summary: conf.Name,
+		Start:   conf.StartTime,
+		End:     conf.EndTime,
+	}})
+}
+
+func (h *Handler) AddSession(c *gin.Context) {
+	var req AddSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	session, err := h.service.AddSession(c.Request.Context(), c.Param("name"), req, auth.UserID(c))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, session)
+}
+
+func (h *Handler) ListSessions(c *gin.Context) {
+	sessions, err := h.service.ListSessions(c.Request.Context(), c.Param("name"), auth.UserID(c))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, sessions)
+}
+
+func (h *Handler) AddTicketType(c *gin.Context) {
+	var req AddTicketTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ticketType, err := h.service.AddTicketType(c.Request.Context(), c.Param("name"), req, auth.UserID(c))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, ticketType)
+}
+
+func (h *Handler) ListTicketTypes(c *gin.Context) {
+	ticketTypes, err := h.service.ListTicketTypes(c.Request.Context(), c.Param("name"), auth.UserID(c))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, ticketTypes)
+}
+
+func (h *Handler) SetCancellationPolicy(c *gin.Context) {
+	var policy CancellationPolicy
+	if err := c.ShouldBindJSON(&policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	conf, err := h.service.SetCancellationPolicy(c.Request.Context(), c.Param("name"), policy, auth.UserID(c))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, conf)
+}
+
+func (h *Handler) AssignRoom(c *gin.Context) {
+	var req AssignRoomRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	conf, err := h.service.AssignRoom(c.Request.Context(), c.Param("name"), req, auth.UserID(c))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, conf)
+}
+
+func (h *Handler) SetSeatMap(c *gin.Context) {
+	var seatMap SeatMap
+	if err := c.ShouldBindJSON(&seatMap); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	conf, err := h.service.SetSeatMap(c.Request.Context(), c.Param("name"), seatMap, auth.UserID(c))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, conf)
+}
+
+func (h *Handler) PublishConference(c *gin.Context) {
+	conf, err := h.service.PublishConference(c.Request.Context(), c.Param("name"), auth.UserID(c))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, conf)
+}
+
+func (h *Handler) CloseRegistration(c *gin.Context) {
+	conf, err := h.service.CloseRegistration(c.Request.Context(), c.Param("name"), auth.UserID(c))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, conf)
+}
+
+func (h *Handler) AddPromoCode(c *gin.Context) {
+	var req AddPromoCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	code, err := h.service.AddPromoCode(c.Request.Context(), c.Param("name"), req, auth.UserID(c))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, code)
+}
+
+func (h *Handler) ListPromoCodes(c *gin.Context) {
+	codes, err := h.service.ListPromoCodes(c.Request.Context(), c.Param("name"), auth.UserID(c))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, codes)
+}
+
+func (h *Handler) AddSeries(c *gin.Context) {
+	var req AddSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The creator owns the series and its occurrences
+	req.OwnerID = auth.UserID(c)
+
+	series, err := h.service.AddSeries(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(errorStatus(err), errorBody(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, series)
+}
+
+func (h *Handler) ListSeries(c *gin.Context) {
+	c.JSON(http.StatusOK, h.service.ListSeries(c.Request.Context()))
+}
+
+func (h *Handler) GetSeries(c *gin.Context) {
+	series, err := h.service.GetSeries(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, series)
+}
+
+func (h *Handler) UpdateSeries(c *gin.Context) {
+	var req UpdateSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	series, err := h.service.UpdateSeries(c.Request.Context(), c.Param("id"), req, auth.UserID(c))
+	if err != nil {
+		c.JSON(errorStatus(err), errorBody(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, series)
+}
+
+func (h *Handler) ListOccurrences(c *gin.Context) {
+	occurrences, err := h.service.ListOccurrences(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, occurrences)
+}
+
+// errorBody lists the broken validation rules along with the error, if there are any.
+func errorBody(err error) gin.H {
+	body := gin.H{"error": err.Error()}
+	var invalid *ValidationError
+	if stderrors.As(err, &invalid) {
+		body["violations"] = invalid.Violations
+	}
+	return body
+}
+
+func errorStatus(err error) int {
+	switch {
+	case stderrors.Is(err, errors.ErrInvalidInput):
+		return http.StatusBadRequest
+	case stderrors.Is(err, errors.ErrForbidden):
+		return http.StatusForbidden
+	case stderrors.Is(err, errors.ErrNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusConflict
+	}
+}
@@ -3,15 +3,373 @@ package conference
 import "time"
 
 type Conference struct {
-	Name           string
-	StartTime      time.Time
-	EndTime        time.Time
-	AvailableSlots int
+	Name           string    `json:"name"`
+	StartTime      time.Time `json:"start_time"`
+	EndTime        time.Time `json:"end_time"`
+	AvailableSlots int       `json:"available_slots"`
+	Capacity       int       `json:"capacity,omitempty"` // seats at creation, checked against the room
+	RoomID         string    `json:"room_id,omitempty"`
+	OwnerID        string    `json:"owner_id,omitempty"`
+	InviteOnly     bool      `json:"invite_only,omitempty"`
+	SeriesID       string    `json:"series_id,omitempty"`
+	TimeZone       string    `json:"time_zone,omitempty"` // IANA name, e.g. Europe/Berlin; UTC when empty
+	Status         string    `json:"status,omitempty"`    // stored state; see State for the effective one
+
+	CancellationPolicy *CancellationPolicy `json:"cancellation_policy,omitempty"`
+	Lottery            *Lottery            `json:"lottery,omitempty"`
+	SeatMap            *SeatMap            `json:"seat_map,omitempty"`
+}
+
+// Lifecycle states of a conference. Draft, published, registration-closed and cancelled are set by
+// the owner; in-progress and completed follow from the clock. Conferences stored without a status
+// count as published.
+const (
+	StatusDraft              = "draft"
+	StatusPublished          = "published"
+	StatusRegistrationClosed = "registration-closed"
+	StatusInProgress         = "in-progress"
+	StatusCompleted          = "completed"
+	StatusCancelled          = "cancelled"
+)
+
+// State returns the lifecycle state of the conference at now.
+func (c *Conference) State(now time.Time) string {
+	switch {
+	case c.Status == StatusDraft || c.Status == StatusCancelled:
+		return c.Status
+	case !now.Before(c.EndTime):
+		return StatusCompleted
+	case !now.Before(c.StartTime):
+		return StatusInProgress
+	case c.Status == StatusRegistrationClosed:
+		return StatusRegistrationClosed
+	}
+	return StatusPublished
+}
+
+// VisibleTo reports whether a user may see the conference: drafts are visible to their owner only.
+func (c *Conference) VisibleTo(userID string) bool {
+	return c.Status != StatusDraft || (c.OwnerID != "" && c.OwnerID == userID)
+}
+
+// RegistrationOpen reports whether the conference takes bookings at now: it is published and has
+// not started.
+func (c *Conference) RegistrationOpen(now time.Time) bool {
+	return c.State(now) == StatusPublished
 }
 
+// AddConferenceRequest gives the schedule either as instants (start_time, end_time) or as wall-clock
+// times in the conference's time zone (local_start_time, local_end_time).
 type AddConferenceRequest struct {
-	Name           string    `json:"name"`
+	Name               string              `json:"name"`
+	StartTime          time.Time           `json:"start_time"`
+	EndTime            time.Time           `json:"end_time"`
+	LocalStartTime     string              `json:"local_start_time"`
+	LocalEndTime       string              `json:"local_end_time"`
+	TimeZone           string              `json:"time_zone"`
+	AvailableSlots     int                 `json:"available_slots"`
+	RoomID             string              `json:"room_id"`
+	InviteOnly         bool                `json:"invite_only"`
+	CancellationPolicy *CancellationPolicy `json:"cancellation_policy"`
+	Lottery            *Lottery            `json:"lottery"`
+	Publish            bool                `json:"publish"` // skip the draft state
+	OwnerID            string              `json:"-"`
+}
+
+// Series is a recurring conference. Its occurrences are ordinary conferences, named after the
+// series and the occurrence date, generated from the rule up to Config.SeriesHorizon ahead.
+type Series struct {
+	ID                 string              `json:"id"`
+	Name               string              `json:"name"`
+	Rule               string              `json:"rule"`
+	StartTime          time.Time           `json:"start_time"` // first occurrence
+	EndTime            time.Time           `json:"end_time"`
+	TimeZone           string              `json:"time_zone,omitempty"` // occurrences keep their local time of day
+	AvailableSlots     int                 `json:"available_slots"`
+	RoomID             string              `json:"room_id,omitempty"`
+	OwnerID            string              `json:"owner_id,omitempty"`
+	InviteOnly         bool                `json:"invite_only,omitempty"`
+	CancellationPolicy *CancellationPolicy `json:"cancellation_policy,omitempty"`
+	GeneratedUntil     time.Time           `json:"generated_until"`
+	Skipped            []SkippedOccurrence `json:"skipped,omitempty"`
+}
+
+// SkippedOccurrence is an occurrence of a series that could not be generated, e.g. because its
+// room was taken or a conference of the same name exists. The series carries on without it.
+type SkippedOccurrence struct {
+	Name      string    `json:"name"`
+	StartTime time.Time `json:"start_time"`
+	Reason    string    `json:"reason"`
+}
+
+// Duration is the length of every occurrence.
+func (s *Series) Duration() time.Duration {
+	return s.EndTime.Sub(s.StartTime)
+}
+
+// AddSeriesRequest gives the first occurrence like AddConferenceRequest.
+type AddSeriesRequest struct {
+	Name               string              `json:"name"`
+	Rule               string              `json:"rule"`
+	StartTime          time.Time           `json:"start_time"`
+	EndTime            time.Time           `json:"end_time"`
+	LocalStartTime     string              `json:"local_start_time"`
+	LocalEndTime       string              `json:"local_end_time"`
+	TimeZone           string              `json:"time_zone"`
+	AvailableSlots     int                 `json:"available_slots"`
+	RoomID             string              `json:"room_id"`
+	InviteOnly         bool                `json:"invite_only"`
+	CancellationPolicy *CancellationPolicy `json:"cancellation_policy"`
+	OwnerID            string              `json:"-"`
+}
+
+// UpdateSeriesRequest changes a series and its future occurrences. Omitted fields stay as they are.
+type UpdateSeriesRequest struct {
+	AvailableSlots     *int                `json:"available_slots"`
+	DurationMinutes    *int                `json:"duration_minutes"`
+	InviteOnly         *bool               `json:"invite_only"`
+	CancellationPolicy *CancellationPolicy `json:"cancellation_policy"`
+}
+
+// SeatMap lays out the numbered seats of a conference in rows. Conference bookings get a seat from it;
+// session bookings do not.
+type SeatMap struct {
+	Rows []SeatRow `json:"rows"`
+}
+
+type SeatRow struct {
+	Label string `json:"label"`
+	Seats []Seat `json:"seats"`
+}
+
+// Seat is one place of a seat map. Labels are unique within the map, e.g. "A12".
+type Seat struct {
+	Label      string `json:"label"`
+	Accessible bool   `json:"accessible,omitempty"`
+}
+
+// Find returns the seat with the given label, or nil.
+func (m *SeatMap) Find(label string) *Seat {
+	for _, row := range m.Rows {
+		for i := range row.Seats {
+			if row.Seats[i].Label == label {
+				return &row.Seats[i]
+			}
+		}
+	}
+	return nil
+}
+
+// Valid reports whether the map labels every row and seat uniquely and has room for capacity people.
+func (m *SeatMap) Valid(capacity int) bool {
+	rows := make(map[string]bool)
+	seats := make(map[string]bool)
+	for _, row := range m.Rows {
+		if row.Label == "" || rows[row.Label] {
+			return false
+		}
+		rows[row.Label] = true
+		for _, seat := range row.Seats {
+			if seat.Label == "" || seats[seat.Label] {
+				return false
+			}
+			seats[seat.Label] = true
+		}
+	}
+	return len(seats) > 0 && len(seats) >= capacity
+}
+
+type AssignRoomRequest struct {
+	RoomID string `json:"room_id"`
+}
+
+// Lottery allocates the seats of an oversubscribed conference by a random draw instead of first come,
+// first served. Bookings made while registration is open are collected as entries; the draw confirms
+// as many as there are seats and waitlists the rest in drawn order. Afterwards booking is first come,
+// first served again.
+type Lottery struct {
+	RegistrationOpens  *time.Time `json:"registration_opens,omitempty"`
+	RegistrationCloses time.Time  `json:"registration_closes"`
+	DrawnAt            *time.Time `json:"drawn_at,omitempty"`
+}
+
+// Pending reports whether the conference allocates seats by a lottery that has not been drawn yet.
+func (l *Lottery) Pending() bool {
+	return l != nil && l.DrawnAt == nil
+}
+
+// Open reports whether entries are accepted at the given time.
+func (l *Lottery) Open(now time.Time) bool {
+	if !l.Pending() {
+		return false
+	}
+	if l.RegistrationOpens != nil && now.Before(*l.RegistrationOpens) {
+		return false
+	}
+	return now.Before(l.RegistrationCloses)
+}
+
+// Valid reports whether registration opens before it closes and closes no later than the start.
+func (l *Lottery) Valid(start time.Time) bool {
+	if l.RegistrationOpens != nil && !l.RegistrationOpens.Before(l.RegistrationCloses) {
+		return false
+	}
+	return !l.RegistrationCloses.After(start) && l.DrawnAt == nil
+}
+
+// CancellationPolicy decides how much of the price is refunded when a booking is cancelled.
+// Cancellations at least FreeUntilDays days before the start are refunded in full; later ones get the
+// best refund window they still fall into, or nothing. Nothing can be cancelled once the event has started.
+type CancellationPolicy struct {
+	FreeUntilDays int            `json:"free_until_days"`
+	RefundWindows []RefundWindow `json:"refund_windows,omitempty"`
+}
+
+// RefundWindow refunds Percent of the price for cancellations at least DaysBefore days before the start.
+type RefundWindow struct {
+	DaysBefore int `json:"days_before"`
+	Percent    int `json:"percent"`
+}
+
+// RefundPercent returns the share of the price refunded for a cancellation made at now of an event
+// starting at start. Without a policy everything is refunded.
+func (p *CancellationPolicy) RefundPercent(start, now time.Time) int {
+	if p == nil {
+		return 100
+	}
+
+	const day = 24 * time.Hour
+	left := start.Sub(now)
+	if left >= time.Duration(p.FreeUntilDays)*day {
+		return 100
+	}
+	percent := 0
+	for _, window := range p.RefundWindows {
+		if left >= time.Duration(window.DaysBefore)*day && window.Percent > percent {
+			percent = window.Percent
+		}
+	}
+	return percent
+}
+
+// Valid reports whether every day count is non-negative and every percentage lies within 0-100.
+func (p *CancellationPolicy) Valid() bool {
+	if p.FreeUntilDays < 0 {
+		return false
+	}
+	for _, window := range p.RefundWindows {
+		if window.DaysBefore < 0 || window.Percent < 0 || window.Percent > 100 {
+			return false
+		}
+	}
+	return true
+}
+
+// Session is a bookable slot of a conference with its own capacity, e.g. a talk in one track.
+type Session struct {
+	ID             string    `json:"id"`
+	ConferenceName string    `json:"conference_name"`
+	Title          string    `json:"title"`
+	Speaker        string    `json:"speaker,omitempty"`
+	Track          string    `json:"track,omitempty"`
+	Room           string    `json:"room,omitempty"`
 	StartTime      time.Time `json:"start_time"`
 	EndTime        time.Time `json:"end_time"`
+	Capacity       int       `json:"capacity"`
 	AvailableSlots int       `json:"available_slots"`
 }
+
+type AddSessionRequest struct {
+	Title     string    `json:"title"`
+	Speaker   string    `json:"speaker"`
+	Track     string    `json:"track"`
+	Room      string    `json:"room"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	Capacity  int       `json:"capacity"`
+}
+
+// TicketType is a named class of conference tickets (e.g. Early Bird, Student) with its own
+// capacity, optional sale window and optional price. Price is in minor units of Currency.
+type TicketType struct {
+	ConferenceName string     `json:"conference_name"`
+	Name           string     `json:"name"`
+	Capacity       int        `json:"capacity"`
+	AvailableSlots int        `json:"available_slots"`
+	SalesStart     *time.Time `json:"sales_start,omitempty"`
+	SalesEnd       *time.Time `json:"sales_end,omitempty"`
+	Price          int64      `json:"price,omitempty"`
+	Currency       string     `json:"currency,omitempty"`
+	Tier           string     `json:"tier,omitempty"` // waitlist tier of bookings of the ticket type, see TierPriority
+}
+
+// OnSale reports whether the ticket type can be booked at the given time.
+func (t *TicketType) OnSale(now time.Time) bool {
+	if t.SalesStart != nil && now.Before(*t.SalesStart) {
+		return false
+	}
+	if t.SalesEnd != nil && !now.Before(*t.SalesEnd) {
+		return false
+	}
+	return true
+}
+
+type AddTicketTypeRequest struct {
+	Name       string     `json:"name"`
+	Capacity   int        `json:"capacity"`
+	SalesStart *time.Time `json:"sales_start"`
+	SalesEnd   *time.Time `json:"sales_end"`
+	Price      int64      `json:"price"`
+	Currency   string     `json:"currency"`
+	Tier       string     `json:"tier"`
+}
+
+// Waitlist tiers, best first. Bookings without a tier are standard.
+const (
+	TierPriority = "priority"
+	TierMember   = "member"
+	TierStandard = "standard"
+)
+
+// ValidTier reports whether tier is empty or one of the waitlist tiers.
+func ValidTier(tier string) bool {
+	switch tier {
+	case "", TierPriority, TierMember, TierStandard:
+		return true
+	}
+	return false
+}
+
+// PromoCode is a promotional or invitation code of a conference. A code can discount the ticket
+// price, admit its holders to an invite-only conference, and set aside seats only its holders can book.
+// Reserved seats are taken from the ticket type the code is limited to, or from the conference.
+type PromoCode struct {
+	ConferenceName  string     `json:"conference_name"`
+	Code            string     `json:"code"`
+	TicketType      string     `json:"ticket_type,omitempty"`
+	Tier            string     `json:"tier,omitempty"` // waitlist tier of bookings made with the code, see TierPriority
+	DiscountPercent int        `json:"discount_percent,omitempty"`
+	MaxUses         int        `json:"max_uses,omitempty"` // 0 means unlimited
+	Uses            int        `json:"uses"`
+	ExpiresAt       *time.Time `json:"expires_at,omitempty"`
+	ReservedSeats   int        `json:"reserved_seats,omitempty"`
+	AvailableSlots  int        `json:"available_slots"` // reserved seats not yet taken
+}
+
+// Usable reports whether the code can still be redeemed uses more times at the given time.
+func (c *PromoCode) Usable(now time.Time, uses int) bool {
+	if c.ExpiresAt != nil && !now.Before(*c.ExpiresAt) {
+		return false
+	}
+	return c.MaxUses == 0 || c.Uses+uses <= c.MaxUses
+}
+
+type AddPromoCodeRequest struct {
+	Code            string     `json:"code"`
+	TicketType      string     `json:"ticket_type"`
+	Tier            string     `json:"tier"`
+	DiscountPercent int        `json:"discount_percent"`
+	MaxUses         int        `json:"max_uses"`
+	ExpiresAt       *time.Time `json:"expires_at"`
+	ReservedSeats   int        `json:"reserved_seats"`
+}
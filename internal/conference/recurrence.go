@@ -0,0 +1,156 @@
+package conference
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxOccurrences bounds open-ended rules (no COUNT or UNTIL).
+const maxOccurrences = 1000
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// Recurrence is the subset of an iCalendar RRULE that series support: FREQ (DAILY, WEEKLY or
+// MONTHLY), INTERVAL, COUNT, UNTIL and, for weekly rules, BYDAY.
+type Recurrence struct {
+	Freq     string
+	Interval int
+	Count    int
+	Until    time.Time
+	ByDay    []time.Weekday // Monday first
+}
+
+// ParseRecurrence parses a rule such as "FREQ=WEEKLY;BYDAY=TU,TH;COUNT=10". An "RRULE:" prefix is allowed.
+func ParseRecurrence(rule string) (*Recurrence, error) {
+	r := &Recurrence{Interval: 1}
+	for _, part := range strings.Split(strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:"), ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, invalidRule("has a malformed part %q", part)
+		}
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			r.Freq = strings.ToUpper(value)
+		case "INTERVAL", "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return nil, invalidRule("%s must be a positive integer", key)
+			}
+			if strings.ToUpper(key) == "INTERVAL" {
+				r.Interval = n
+			} else {
+				r.Count = n
+			}
+		case "UNTIL":
+			until, err := parseUntil(value)
+			if err != nil {
+				return nil, invalidRule("UNTIL must look like 20261231 or 20261231T235959Z")
+			}
+			r.Until = until
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				weekday, ok := weekdays[strings.ToUpper(day)]
+				if !ok {
+					return nil, invalidRule("has an unknown BYDAY value %q", day)
+				}
+				r.ByDay = append(r.ByDay, weekday)
+			}
+		default:
+			return nil, invalidRule("part %s is not supported", key)
+		}
+	}
+
+	switch r.Freq {
+	case "WEEKLY":
+	case "DAILY", "MONTHLY":
+		if len(r.ByDay) > 0 {
+			return nil, invalidRule("BYDAY is only supported for weekly rules")
+		}
+	default:
+		return nil, invalidRule("FREQ must be DAILY, WEEKLY or MONTHLY")
+	}
+	if r.Count > 0 && !r.Until.IsZero() {
+		return nil, invalidRule("COUNT and UNTIL cannot be combined")
+	}
+	// A day listed twice is still one occurrence
+	sort.Slice(r.ByDay, func(i, j int) bool { return daysFromMonday(r.ByDay[i]) < daysFromMonday(r.ByDay[j]) })
+	r.ByDay = slices.Compact(r.ByDay)
+	return r, nil
+}
+
+// invalidRule reports a problem with a recurrence rule against the rule field of a request.
+func invalidRule(format string, args ...any) error {
+	return &ValidationError{Violations: []Violation{{Field: "rule", Message: fmt.Sprintf(format, args...)}}}
+}
+
+func parseUntil(value string) (time.Time, error) {
+	if until, err := time.Parse("20060102T150405Z", value); err == nil {
+		return until, nil
+	}
+	until, err := time.Parse("20060102", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	// A date-only UNTIL includes the whole day
+	return until.Add(24*time.Hour - time.Nanosecond), nil
+}
+
+// Occurrences returns the start times of the occurrences that begin before limit. Occurrences keep
+// the time of day of start; the first is the first match at or after start. COUNT is counted from
+// start, so the same rule always yields the same occurrences whatever the limit.
+func (r *Recurrence) Occurrences(start, limit time.Time) []time.Time {
+	var starts []time.Time
+	for period := 0; ; period++ {
+		for _, t := range r.period(start, period) {
+			if t.Before(start) {
+				continue
+			}
+			if !t.Before(limit) || (!r.Until.IsZero() && t.After(r.Until)) || (r.Count > 0 && len(starts) == r.Count) || len(starts) == maxOccurrences {
+				return starts
+			}
+			starts = append(starts, t)
+		}
+	}
+}
+
+// period returns the candidate start times of the n-th period of the rule.
+func (r *Recurrence) period(start time.Time, n int) []time.Time {
+	switch r.Freq {
+	case "DAILY":
+		return []time.Time{start.AddDate(0, 0, n*r.Interval)}
+	case "MONTHLY":
+		// Months without the day of start (e.g. the 31st) are skipped, as in RFC 5545
+		t := start.AddDate(0, n*r.Interval, 0)
+		if t.Day() != start.Day() {
+			return nil
+		}
+		return []time.Time{t}
+	}
+
+	week := start.AddDate(0, 0, 7*n*r.Interval)
+	if len(r.ByDay) == 0 {
+		return []time.Time{week}
+	}
+	monday := week.AddDate(0, 0, -daysFromMonday(week.Weekday()))
+	days := make([]time.Time, 0, len(r.ByDay))
+	for _, weekday := range r.ByDay {
+		days = append(days, monday.AddDate(0, 0, daysFromMonday(weekday)))
+	}
+	return days
+}
+
+func daysFromMonday(weekday time.Weekday) int {
+	return (int(weekday) + 6) % 7
+}
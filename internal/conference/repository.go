@@ -2,27 +2,67 @@ package conference
 
 import (
 	"conference-booking/pkg/errors"
+	"conference-booking/pkg/query"
+	"context"
+	"sort"
 	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Repository interface {
-	Create(conference *Conference) error
-	FindByName(name string) (*Conference, error)
-	Update(conference *Conference) error
+	Create(ctx context.Context, conference *Conference) error
+	FindByName(ctx context.Context, name string) (*Conference, error)
+	Update(ctx context.Context, conference *Conference) error
+	List(ctx context.Context, q query.Query) (query.Page[*Conference], error)
+	CreateSession(ctx context.Context, session *Session) error
+	FindSession(ctx context.Context, id string) (*Session, error)
+	UpdateSession(ctx context.Context, session *Session) error
+	FindSessions(ctx context.Context, conferenceName string) []*Session
+	CreateTicketType(ctx context.Context, ticketType *TicketType) error
+	FindTicketType(ctx context.Context, conferenceName, name string) (*TicketType, error)
+	UpdateTicketType(ctx context.Context, ticketType *TicketType) error
+	FindTicketTypes(ctx context.Context, conferenceName string) []*TicketType
+	CreatePromoCode(ctx context.Context, code *PromoCode) error
+	FindPromoCode(ctx context.Context, conferenceName, code string) (*PromoCode, error)
+	UpdatePromoCode(ctx context.Context, code *PromoCode) error
+	FindPromoCodes(ctx context.Context, conferenceName string) []*PromoCode
+	FindExpiredReservations(ctx context.Context, now time.Time) []*PromoCode
+	FindDueLotteries(ctx context.Context, now time.Time) []*Conference
+	ClaimDraw(ctx context.Context, name string, drawnAt time.Time) (*Conference, error)
+	FindByRoom(ctx context.Context, roomID string) []*Conference
+	FindBySeries(ctx context.Context, seriesID string) []*Conference
+	CreateSeries(ctx context.Context, series *Series, occurrences []*Conference) error
+	FindSeries(ctx context.Context, id string) (*Series, error)
+	UpdateSeries(ctx context.Context, series *Series) error
+	ListSeries(ctx context.Context) []*Series
 }
 
 type inMemoryRepository struct {
 	conferences map[string]*Conference
+	sessions    map[string]*Session
+	ticketTypes map[string]*TicketType // keyed by conference name and ticket type name
+	promoCodes  map[string]*PromoCode  // keyed by conference name and code
+	series      map[string]*Series
 	mutex       sync.Mutex
 }
 
 func NewInMemoryRepository() Repository {
 	return &inMemoryRepository{
 		conferences: make(map[string]*Conference),
+		sessions:    make(map[string]*Session),
+		ticketTypes: make(map[string]*TicketType),
+		promoCodes:  make(map[string]*PromoCode),
+		series:      make(map[string]*Series),
 	}
 }
 
-func (r *inMemoryRepository) Create(conference *Conference) error {
+func (r *inMemoryRepository) Create(ctx context.Context, conference *Conference) error {
+	_, span := tracer.Start(ctx, "conference.Repository.Create", trace.WithAttributes(attribute.String("conference.id", conference.Name)))
+	defer span.End()
+
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
@@ -34,7 +74,10 @@ func (r *inMemoryRepository) Create(conference *Conference) error {
 	return nil
 }
 
-func (r *inMemoryRepository) FindByName(name string) (*Conference, error) {
+func (r *inMemoryRepository) FindByName(ctx context.Context, name string) (*Conference, error) {
+	_, span := tracer.Start(ctx, "conference.Repository.FindByName", trace.WithAttributes(attribute.String("conference.id", name)))
+	defer span.End()
+
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
@@ -48,7 +91,10 @@ func (r *inMemoryRepository) FindByName(name string) (*Conference, error) {
 
 // Update updates the details of an existing conference.
 // This is primarily used to modify the available slots or other dynamic fields.
-func (r *inMemoryRepository) Update(conference *Conference) error {
+func (r *inMemoryRepository) Update(ctx context.Context, conference *Conference) error {
+	_, span := tracer.Start(ctx, "conference.Repository.Update", trace.WithAttributes(attribute.String("conference.id", conference.Name)))
+	defer span.End()
+
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
@@ -60,3 +106,416 @@ func (r *inMemoryRepository) Update(conference *Conference) error {
 	r.conferences[conference.Name] = conference
 	return nil
 }
+
+// List returns conferences filtered by name and a start-time window.
+func (r *inMemoryRepository) List(ctx context.Context, q query.Query) (query.Page[*Conference], error) {
+	_, span := tracer.Start(ctx, "conference.Repository.List")
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	// Drafts are listed only to their owner, who is passed as q.UserID
+	now := time.Now()
+	var conferences []*Conference
+	for _, conference := range r.conferences {
+		if q.ConferenceID != "" && conference.Name != q.ConferenceID {
+			continue
+		}
+		if !conference.VisibleTo(q.UserID) {
+			continue
+		}
+		if q.Status != "" && conference.State(now) != q.Status {
+			continue
+		}
+		if !q.InRange(conference.StartTime) {
+			continue
+		}
+		conferences = append(conferences, conference)
+	}
+
+	return query.Apply(conferences, q, func(c *Conference) string { return c.Name }, map[string]query.Key[*Conference]{
+		"name":            func(c *Conference) string { return c.Name },
+		"start_time":      func(c *Conference) string { return query.TimeKey(c.StartTime) },
+		"end_time":        func(c *Conference) string { return query.TimeKey(c.EndTime) },
+		"available_slots": func(c *Conference) string { return query.IntKey(c.AvailableSlots) },
+	})
+}
+
+func (r *inMemoryRepository) CreateSession(ctx context.Context, session *Session) error {
+	_, span := tracer.Start(ctx, "conference.Repository.CreateSession", trace.WithAttributes(attribute.String("conference.id", session.ConferenceName), attribute.String("session.id", session.ID)))
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.conferences[session.ConferenceName]; !exists {
+		return errors.ErrNotFound
+	}
+	if _, exists := r.sessions[session.ID]; exists {
+		return errors.ErrConflict
+	}
+
+	r.sessions[session.ID] = session
+	return nil
+}
+
+func (r *inMemoryRepository) FindSession(ctx context.Context, id string) (*Session, error) {
+	_, span := tracer.Start(ctx, "conference.Repository.FindSession", trace.WithAttributes(attribute.String("session.id", id)))
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	session, exists := r.sessions[id]
+	if !exists {
+		return nil, errors.ErrNotFound
+	}
+	return session, nil
+}
+
+func (r *inMemoryRepository) UpdateSession(ctx context.Context, session *Session) error {
+	_, span := tracer.Start(ctx, "conference.Repository.UpdateSession", trace.WithAttributes(attribute.String("session.id", session.ID)))
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.sessions[session.ID]; !exists {
+		return errors.ErrNotFound
+	}
+
+	r.sessions[session.ID] = session
+	return nil
+}
+
+// FindSessions returns the sessions of a conference ordered by start time.
+func (r *inMemoryRepository) FindSessions(ctx context.Context, conferenceName string) []*Session {
+	_, span := tracer.Start(ctx, "conference.Repository.FindSessions", trace.WithAttributes(attribute.String("conference.id", conferenceName)))
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	sessions := []*Session{}
+	for _, session := range r.sessions {
+		if session.ConferenceName == conferenceName {
+			sessions = append(sessions, session)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		if !sessions[i].StartTime.Equal(sessions[j].StartTime) {
+			return sessions[i].StartTime.Before(sessions[j].StartTime)
+		}
+		return sessions[i].ID < sessions[j].ID
+	})
+	return sessions
+}
+
+func ticketTypeKey(conferenceName, name string) string {
+	return conferenceName + "/" + name
+}
+
+func (r *inMemoryRepository) CreateTicketType(ctx context.Context, ticketType *TicketType) error {
+	_, span := tracer.Start(ctx, "conference.Repository.CreateTicketType", trace.WithAttributes(attribute.String("conference.id", ticketType.ConferenceName), attribute.String("ticket_type", ticketType.Name)))
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.conferences[ticketType.ConferenceName]; !exists {
+		return errors.ErrNotFound
+	}
+	key := ticketTypeKey(ticketType.ConferenceName, ticketType.Name)
+	if _, exists := r.ticketTypes[key]; exists {
+		return errors.ErrConflict
+	}
+
+	r.ticketTypes[key] = ticketType
+	return nil
+}
+
+func (r *inMemoryRepository) FindTicketType(ctx context.Context, conferenceName, name string) (*TicketType, error) {
+	_, span := tracer.Start(ctx, "conference.Repository.FindTicketType", trace.WithAttributes(attribute.String("conference.id", conferenceName), attribute.String("ticket_type", name)))
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	ticketType, exists := r.ticketTypes[ticketTypeKey(conferenceName, name)]
+	if !exists {
+		return nil, errors.ErrNotFound
+	}
+	return ticketType, nil
+}
+
+func (r *inMemoryRepository) UpdateTicketType(ctx context.Context, ticketType *TicketType) error {
+	_, span := tracer.Start(ctx, "conference.Repository.UpdateTicketType", trace.WithAttributes(attribute.String("conference.id", ticketType.ConferenceName), attribute.String("ticket_type", ticketType.Name)))
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	key := ticketTypeKey(ticketType.ConferenceName, ticketType.Name)
+	if _, exists := r.ticketTypes[key]; !exists {
+		return errors.ErrNotFound
+	}
+
+	r.ticketTypes[key] = ticketType
+	return nil
+}
+
+// FindTicketTypes returns the ticket types of a conference ordered by name.
+func (r *inMemoryRepository) FindTicketTypes(ctx context.Context, conferenceName string) []*TicketType {
+	_, span := tracer.Start(ctx, "conference.Repository.FindTicketTypes", trace.WithAttributes(attribute.String("conference.id", conferenceName)))
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	ticketTypes := []*TicketType{}
+	for _, ticketType := range r.ticketTypes {
+		if ticketType.ConferenceName == conferenceName {
+			ticketTypes = append(ticketTypes, ticketType)
+		}
+	}
+	sort.Slice(ticketTypes, func(i, j int) bool {
+		return ticketTypes[i].Name < ticketTypes[j].Name
+	})
+	return ticketTypes
+}
+
+func promoCodeKey(conferenceName, code string) string {
+	return conferenceName + "/" + code
+}
+
+func (r *inMemoryRepository) CreatePromoCode(ctx context.Context, code *PromoCode) error {
+	_, span := tracer.Start(ctx, "conference.Repository.CreatePromoCode", trace.WithAttributes(attribute.String("conference.id", code.ConferenceName)))
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.conferences[code.ConferenceName]; !exists {
+		return errors.ErrNotFound
+	}
+	key := promoCodeKey(code.ConferenceName, code.Code)
+	if _, exists := r.promoCodes[key]; exists {
+		return errors.ErrConflict
+	}
+
+	r.promoCodes[key] = code
+	return nil
+}
+
+func (r *inMemoryRepository) FindPromoCode(ctx context.Context, conferenceName, code string) (*PromoCode, error) {
+	_, span := tracer.Start(ctx, "conference.Repository.FindPromoCode", trace.WithAttributes(attribute.String("conference.id", conferenceName)))
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	promoCode, exists := r.promoCodes[promoCodeKey(conferenceName, code)]
+	if !exists {
+		return nil, errors.ErrNotFound
+	}
+	return promoCode, nil
+}
+
+func (r *inMemoryRepository) UpdatePromoCode(ctx context.Context, code *PromoCode) error {
+	_, span := tracer.Start(ctx, "conference.Repository.UpdatePromoCode", trace.WithAttributes(attribute.String("conference.id", code.ConferenceName)))
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	key := promoCodeKey(code.ConferenceName, code.Code)
+	if _, exists := r.promoCodes[key]; !exists {
+		return errors.ErrNotFound
+	}
+
+	r.promoCodes[key] = code
+	return nil
+}
+
+// FindPromoCodes returns the codes of a conference ordered by code.
+func (r *inMemoryRepository) FindPromoCodes(ctx context.Context, conferenceName string) []*PromoCode {
+	_, span := tracer.Start(ctx, "conference.Repository.FindPromoCodes", trace.WithAttributes(attribute.String("conference.id", conferenceName)))
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	codes := []*PromoCode{}
+	for _, code := range r.promoCodes {
+		if code.ConferenceName == conferenceName {
+			codes = append(codes, code)
+		}
+	}
+	sort.Slice(codes, func(i, j int) bool {
+		return codes[i].Code < codes[j].Code
+	})
+	return codes
+}
+
+// FindByRoom returns the conferences held in a room.
+func (r *inMemoryRepository) FindByRoom(ctx context.Context, roomID string) []*Conference {
+	_, span := tracer.Start(ctx, "conference.Repository.FindByRoom", trace.WithAttributes(attribute.String("room.id", roomID)))
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var conferences []*Conference
+	for _, conference := range r.conferences {
+		if conference.RoomID == roomID {
+			conferences = append(conferences, conference)
+		}
+	}
+	return conferences
+}
+
+// FindBySeries returns the occurrences of a series, earliest first.
+func (r *inMemoryRepository) FindBySeries(ctx context.Context, seriesID string) []*Conference {
+	_, span := tracer.Start(ctx, "conference.Repository.FindBySeries", trace.WithAttributes(attribute.String("series.id", seriesID)))
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	conferences := []*Conference{}
+	for _, conference := range r.conferences {
+		if conference.SeriesID == seriesID {
+			conferences = append(conferences, conference)
+		}
+	}
+	sort.Slice(conferences, func(i, j int) bool {
+		return conferences[i].StartTime.Before(conferences[j].StartTime)
+	})
+	return conferences
+}
+
+// CreateSeries stores a series together with its first occurrences, all or nothing.
+func (r *inMemoryRepository) CreateSeries(ctx context.Context, series *Series, occurrences []*Conference) error {
+	_, span := tracer.Start(ctx, "conference.Repository.CreateSeries", trace.WithAttributes(attribute.String("series.id", series.ID)))
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, existing := range r.series {
+		if existing.ID == series.ID || existing.Name == series.Name {
+			return errors.ErrConflict
+		}
+	}
+	for _, conference := range occurrences {
+		if _, exists := r.conferences[conference.Name]; exists {
+			return errors.ErrConflict
+		}
+	}
+
+	r.series[series.ID] = series
+	for _, conference := range occurrences {
+		r.conferences[conference.Name] = conference
+	}
+	return nil
+}
+
+func (r *inMemoryRepository) FindSeries(ctx context.Context, id string) (*Series, error) {
+	_, span := tracer.Start(ctx, "conference.Repository.FindSeries", trace.WithAttributes(attribute.String("series.id", id)))
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	series, exists := r.series[id]
+	if !exists {
+		return nil, errors.ErrNotFound
+	}
+	return series, nil
+}
+
+func (r *inMemoryRepository) UpdateSeries(ctx context.Context, series *Series) error {
+	_, span := tracer.Start(ctx, "conference.Repository.UpdateSeries", trace.WithAttributes(attribute.String("series.id", series.ID)))
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.series[series.ID]; !exists {
+		return errors.ErrNotFound
+	}
+	r.series[series.ID] = series
+	return nil
+}
+
+// ListSeries returns all series, ordered by name.
+func (r *inMemoryRepository) ListSeries(ctx context.Context) []*Series {
+	_, span := tracer.Start(ctx, "conference.Repository.ListSeries")
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	series := make([]*Series, 0, len(r.series))
+	for _, s := range r.series {
+		series = append(series, s)
+	}
+	sort.Slice(series, func(i, j int) bool { return series[i].Name < series[j].Name })
+	return series
+}
+
+// FindDueLotteries returns conferences whose lottery registration has closed but which have not been
+// drawn, leaving out cancelled ones.
+func (r *inMemoryRepository) FindDueLotteries(ctx context.Context, now time.Time) []*Conference {
+	_, span := tracer.Start(ctx, "conference.Repository.FindDueLotteries")
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var due []*Conference
+	for _, conference := range r.conferences {
+		if conference.Lottery.Pending() && !now.Before(conference.Lottery.RegistrationCloses) && conference.Status != StatusCancelled {
+			due = append(due, conference)
+		}
+	}
+	return due
+}
+
+// ClaimDraw marks the pending lottery of a conference as drawn, so that exactly one caller runs the
+// draw. It returns ErrConflict when the lottery is not pending.
+func (r *inMemoryRepository) ClaimDraw(ctx context.Context, name string, drawnAt time.Time) (*Conference, error) {
+	_, span := tracer.Start(ctx, "conference.Repository.ClaimDraw", trace.WithAttributes(attribute.String("conference.id", name)))
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	conference, exists := r.conferences[name]
+	if !exists {
+		return nil, errors.ErrNotFound
+	}
+	if !conference.Lottery.Pending() {
+		return nil, errors.ErrConflict
+	}
+	conference.Lottery.DrawnAt = &drawnAt
+	return conference, nil
+}
+
+// FindExpiredReservations returns expired codes that still hold reserved seats.
+func (r *inMemoryRepository) FindExpiredReservations(ctx context.Context, now time.Time) []*PromoCode {
+	_, span := tracer.Start(ctx, "conference.Repository.FindExpiredReservations")
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var codes []*PromoCode
+	for _, code := range r.promoCodes {
+		if code.AvailableSlots > 0 && code.ExpiresAt != nil && !now.Before(*code.ExpiresAt) {
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
@@ -0,0 +1,195 @@
+package conference
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"conference-booking/pkg/errors"
+)
+
+// Violation is one broken validation rule, reported against a request field.
+type Violation struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every rule a request breaks. It matches errors.ErrInvalidInput.
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		messages[i] = v.Field + " " + v.Message
+	}
+	return errors.ErrInvalidInput.Error() + ": " + strings.Join(messages, "; ")
+}
+
+func (e *ValidationError) Unwrap() error {
+	return errors.ErrInvalidInput
+}
+
+// Rule checks one aspect of a conference. now is when the conference is being created; it is zero
+// when an existing conference is edited, and rules about creation time then pass. Rules about times
+// also pass when the schedule itself could not be resolved, which is reported on its own.
+type Rule interface {
+	Check(conf *Conference, now time.Time) []Violation
+}
+
+// RuleFunc lets a plain function act as a Rule.
+type RuleFunc func(conf *Conference, now time.Time) []Violation
+
+func (f RuleFunc) Check(conf *Conference, now time.Time) []Violation {
+	return f(conf, now)
+}
+
+// RuleSet is the validation applied to new conferences, series and series edits.
+type RuleSet []Rule
+
+// Check returns the violations of all rules.
+func (rs RuleSet) Check(conf *Conference, now time.Time) []Violation {
+	var violations []Violation
+	for _, rule := range rs {
+		violations = append(violations, rule.Check(conf, now)...)
+	}
+	return violations
+}
+
+// RuleConfig configures the built-in rules. Zero values disable a rule, except that end_time must
+// always follow start_time and seats can never be negative.
+type RuleConfig struct {
+	MaxDuration   time.Duration // measured on the local wall clock
+	MinLeadTime   time.Duration // how long before its start a conference must be created
+	NoPastStart   bool
+	MinCapacity   int
+	MaxCapacity   int
+	NamePattern   string // regular expression the whole name must match
+	NameMaxLength int
+}
+
+// DefaultRuleConfig is used unless the deployment configures its own.
+var DefaultRuleConfig = RuleConfig{
+	MaxDuration:   12 * time.Hour,
+	NoPastStart:   true,
+	MinCapacity:   1,
+	NamePattern:   `\S.*`,
+	NameMaxLength: 100,
+}
+
+// NewRuleSet builds the built-in rules from a configuration.
+func NewRuleSet(cfg RuleConfig) (RuleSet, error) {
+	rules := RuleSet{EndAfterStart()}
+	if cfg.MaxDuration > 0 {
+		rules = append(rules, MaxDuration(cfg.MaxDuration))
+	}
+	if cfg.NoPastStart {
+		rules = append(rules, NoPastStart())
+	}
+	if cfg.MinLeadTime > 0 {
+		rules = append(rules, MinLeadTime(cfg.MinLeadTime))
+	}
+	if cfg.MaxCapacity > 0 && cfg.MaxCapacity < cfg.MinCapacity {
+		return nil, fmt.Errorf("maximum capacity %d is below minimum capacity %d", cfg.MaxCapacity, cfg.MinCapacity)
+	}
+	rules = append(rules, CapacityBounds(max(cfg.MinCapacity, 0), cfg.MaxCapacity))
+	if cfg.NamePattern != "" || cfg.NameMaxLength > 0 {
+		rule, err := NameFormat(cfg.NamePattern, cfg.NameMaxLength)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// MustRuleSet is NewRuleSet for configurations known to be valid.
+func MustRuleSet(cfg RuleConfig) RuleSet {
+	rules, err := NewRuleSet(cfg)
+	if err != nil {
+		panic(err)
+	}
+	return rules
+}
+
+// EndAfterStart requires the conference not to end before it starts.
+func EndAfterStart() Rule {
+	return RuleFunc(func(conf *Conference, now time.Time) []Violation {
+		if conf.StartTime.IsZero() || conf.EndTime.IsZero() || !conf.EndTime.Before(conf.StartTime) {
+			return nil
+		}
+		return []Violation{{Field: "end_time", Message: "must not be before start_time"}}
+	})
+}
+
+// MaxDuration limits how long a conference lasts on the wall clock of its time zone: 09:00 to
+// 21:00 local time is 12 hours even on the day clocks change.
+func MaxDuration(max time.Duration) Rule {
+	return RuleFunc(func(conf *Conference, now time.Time) []Violation {
+		if conf.StartTime.IsZero() || conf.EndTime.IsZero() {
+			return nil
+		}
+		loc := conf.Location()
+		if wallClock(conf.EndTime, loc).Sub(wallClock(conf.StartTime, loc)) <= max {
+			return nil
+		}
+		return []Violation{{Field: "end_time", Message: fmt.Sprintf("must be at most %s after start_time in local time", max)}}
+	})
+}
+
+// NoPastStart rejects conferences created after they start.
+func NoPastStart() Rule {
+	return RuleFunc(func(conf *Conference, now time.Time) []Violation {
+		if now.IsZero() || conf.StartTime.IsZero() || !conf.StartTime.Before(now) {
+			return nil
+		}
+		return []Violation{{Field: "start_time", Message: "must not be in the past"}}
+	})
+}
+
+// MinLeadTime requires conferences to be created at least lead before they start.
+func MinLeadTime(lead time.Duration) Rule {
+	return RuleFunc(func(conf *Conference, now time.Time) []Violation {
+		if now.IsZero() || conf.StartTime.IsZero() || !conf.StartTime.Before(now.Add(lead)) {
+			return nil
+		}
+		return []Violation{{Field: "start_time", Message: fmt.Sprintf("must be at least %s ahead", lead)}}
+	})
+}
+
+// CapacityBounds keeps the seats of a conference within [min, max]; a max of 0 means no upper bound.
+func CapacityBounds(min, max int) Rule {
+	return RuleFunc(func(conf *Conference, now time.Time) []Violation {
+		switch {
+		case conf.Capacity < min:
+			return []Violation{{Field: "available_slots", Message: fmt.Sprintf("must be at least %d", min)}}
+		case max > 0 && conf.Capacity > max:
+			return []Violation{{Field: "available_slots", Message: fmt.Sprintf("must be at most %d", max)}}
+		}
+		return nil
+	})
+}
+
+// NameFormat requires names to match pattern in full and to be at most maxLength characters long;
+// an empty pattern or a maxLength of 0 skips that check.
+func NameFormat(pattern string, maxLength int) (Rule, error) {
+	var re *regexp.Regexp
+	if pattern != "" {
+		var err error
+		if re, err = regexp.Compile(`^(?:` + pattern + `)$`); err != nil {
+			return nil, fmt.Errorf("invalid name pattern: %w", err)
+		}
+	}
+	return RuleFunc(func(conf *Conference, now time.Time) []Violation {
+		var violations []Violation
+		if re != nil && !re.MatchString(conf.Name) {
+			violations = append(violations, Violation{Field: "name", Message: "must match " + pattern})
+		}
+		if maxLength > 0 && len([]rune(conf.Name)) > maxLength {
+			violations = append(violations, Violation{Field: "name", Message: fmt.Sprintf("must be at most %d characters", maxLength)})
+		}
+		return violations
+	}), nil
+}
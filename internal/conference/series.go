@@ -0,0 +1,277 @@
+package conference
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"time"
+
+	"conference-booking/pkg/errors"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// AddSeries creates a series and its occurrences within the horizon. The creator becomes the owner
+// of the series and of every occurrence.
+func (s *service) AddSeries(ctx context.Context, req AddSeriesRequest) (*Series, error) {
+	ctx, span := tracer.Start(ctx, "conference.Service.AddSeries", trace.WithAttributes(attribute.String("series.name", req.Name)))
+	defer span.End()
+
+	start, end, violations := resolveSchedule(req.TimeZone, req.StartTime, req.EndTime, req.LocalStartTime, req.LocalEndTime)
+	if req.CancellationPolicy != nil && !req.CancellationPolicy.Valid() {
+		violations = append(violations, Violation{Field: "cancellation_policy", Message: "needs non-negative days and percentages within 0-100"})
+	}
+	var invalidRule *ValidationError
+	if _, err := ParseRecurrence(req.Rule); stderrors.As(err, &invalidRule) {
+		violations = append(violations, invalidRule.Violations...)
+	}
+
+	series := &Series{
+		ID:                 uuid.New().String(),
+		Name:               req.Name,
+		Rule:               req.Rule,
+		StartTime:          start,
+		EndTime:            end,
+		TimeZone:           req.TimeZone,
+		AvailableSlots:     req.AvailableSlots,
+		RoomID:             req.RoomID,
+		OwnerID:            req.OwnerID,
+		InviteOnly:         req.InviteOnly,
+		CancellationPolicy: req.CancellationPolicy,
+	}
+	violations = append(violations, s.rules.Check(series.template(), time.Now())...)
+	if len(violations) > 0 {
+		return nil, &ValidationError{Violations: violations}
+	}
+
+	limit := time.Now().Add(s.seriesHorizon)
+	occurrences, err := s.occurrences(series, limit)
+	if err != nil {
+		return nil, err
+	}
+	if len(occurrences) == 0 {
+		return nil, &ValidationError{Violations: []Violation{{Field: "rule", Message: "has no occurrences within the horizon"}}}
+	}
+	for _, conf := range occurrences {
+		if err := s.checkOccurrence(ctx, conf); err != nil {
+			return nil, err
+		}
+	}
+
+	series.GeneratedUntil = limit
+	if err := s.repo.CreateSeries(ctx, series, occurrences); err != nil {
+		return nil, err
+	}
+	return series, nil
+}
+
+func (s *service) ListSeries(ctx context.Context) []*Series {
+	ctx, span := tracer.Start(ctx, "conference.Service.ListSeries")
+	defer span.End()
+
+	return s.repo.ListSeries(ctx)
+}
+
+func (s *service) GetSeries(ctx context.Context, id string) (*Series, error) {
+	ctx, span := tracer.Start(ctx, "conference.Service.GetSeries", trace.WithAttributes(attribute.String("series.id", id)))
+	defer span.End()
+
+	return s.repo.FindSeries(ctx, id)
+}
+
+// ListOccurrences returns the generated occurrences of a series, earliest first.
+func (s *service) ListOccurrences(ctx context.Context, id string) ([]*Conference, error) {
+	ctx, span := tracer.Start(ctx, "conference.Service.ListOccurrences", trace.WithAttributes(attribute.String("series.id", id)))
+	defer span.End()
+
+	if _, err := s.repo.FindSeries(ctx, id); err != nil {
+		return nil, err
+	}
+	return s.repo.FindBySeries(ctx, id), nil
+}
+
+// UpdateSeries changes a series and every occurrence that has not started yet. Past occurrences keep
+// their details. Capacity changes keep existing bookings, so a capacity below the seats already taken
+// in a future occurrence is rejected. Only the owner may edit a series.
+func (s *service) UpdateSeries(ctx context.Context, id string, req UpdateSeriesRequest, requesterID string) (*Series, error) {
+	ctx, span := tracer.Start(ctx, "conference.Service.UpdateSeries", trace.WithAttributes(attribute.String("series.id", id)))
+	defer span.End()
+
+	series, err := s.repo.FindSeries(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if series.OwnerID == "" || series.OwnerID != requesterID {
+		return nil, errors.ErrForbidden
+	}
+
+	updated := *series
+	if req.AvailableSlots != nil {
+		updated.AvailableSlots = *req.AvailableSlots
+	}
+	if req.DurationMinutes != nil {
+		updated.EndTime = updated.StartTime.Add(time.Duration(*req.DurationMinutes) * time.Minute)
+	}
+	if req.InviteOnly != nil {
+		updated.InviteOnly = *req.InviteOnly
+	}
+	var violations []Violation
+	if req.CancellationPolicy != nil {
+		if !req.CancellationPolicy.Valid() {
+			violations = append(violations, Violation{Field: "cancellation_policy", Message: "needs non-negative days and percentages within 0-100"})
+		}
+		updated.CancellationPolicy = req.CancellationPolicy
+	}
+	// Edits are not creations, so rules about creation time do not apply
+	violations = append(violations, s.rules.Check(updated.template(), time.Time{})...)
+	if len(violations) > 0 {
+		return nil, &ValidationError{Violations: violations}
+	}
+
+	// Check every future occurrence before changing any of them
+	now := time.Now()
+	var future []*Conference
+	for _, conf := range s.repo.FindBySeries(ctx, id) {
+		if !conf.StartTime.After(now) {
+			continue
+		}
+		occurrence := *conf
+		applySeries(&occurrence, &updated)
+		if occurrence.AvailableSlots < 0 {
+			return nil, fmt.Errorf("%w: occurrence %s already has more bookings than %d seats", errors.ErrConflict, conf.Name, updated.AvailableSlots)
+		}
+		if occurrence.RoomID != "" {
+			if err := s.checkRoom(ctx, &occurrence); err != nil {
+				return nil, err
+			}
+		}
+		future = append(future, conf)
+	}
+
+	for _, conf := range future {
+		applySeries(conf, &updated)
+		if err := s.repo.Update(ctx, conf); err != nil {
+			return nil, err
+		}
+	}
+	*series = updated
+	if err := s.repo.UpdateSeries(ctx, series); err != nil {
+		return nil, err
+	}
+	return series, nil
+}
+
+// template returns the first occurrence of the series as a conference, for validation.
+func (s *Series) template() *Conference {
+	return &Conference{
+		Name:               s.Name,
+		StartTime:          s.StartTime,
+		EndTime:            s.EndTime,
+		AvailableSlots:     s.AvailableSlots,
+		Capacity:           s.AvailableSlots,
+		RoomID:             s.RoomID,
+		OwnerID:            s.OwnerID,
+		InviteOnly:         s.InviteOnly,
+		TimeZone:           s.TimeZone,
+		CancellationPolicy: s.CancellationPolicy,
+	}
+}
+
+// applySeries copies the series-level details onto an occurrence, keeping the seats already taken.
+func applySeries(conf *Conference, series *Series) {
+	conf.AvailableSlots += series.AvailableSlots - conf.Capacity
+	conf.Capacity = series.AvailableSlots
+	conf.EndTime = conf.StartTime.Add(series.Duration())
+	conf.InviteOnly = series.InviteOnly
+	conf.CancellationPolicy = series.CancellationPolicy
+}
+
+func (s *service) StartSeriesGeneration(interval time.Duration) {
+	go func() {
+		for {
+			time.Sleep(interval)
+			s.generateOccurrences(context.Background(), time.Now())
+		}
+	}()
+}
+
+// generateOccurrences moves the horizon of every series forward. An occurrence that cannot be
+// created (e.g. because the room is taken) is recorded on the series as skipped, and the others
+// are generated regardless.
+func (s *service) generateOccurrences(ctx context.Context, now time.Time) {
+	ctx, span := tracer.Start(ctx, "conference.Service.generateOccurrences")
+	defer span.End()
+
+	limit := now.Add(s.seriesHorizon)
+	for _, series := range s.repo.ListSeries(ctx) {
+		if !limit.After(series.GeneratedUntil) {
+			continue
+		}
+		occurrences, err := s.occurrences(series, limit)
+		if err != nil {
+			span.RecordError(err)
+			continue
+		}
+		for _, conf := range occurrences {
+			err := s.checkOccurrence(ctx, conf)
+			if err == nil {
+				err = s.repo.Create(ctx, conf)
+			}
+			if err != nil {
+				series.Skipped = append(series.Skipped, SkippedOccurrence{Name: conf.Name, StartTime: conf.StartTime, Reason: err.Error()})
+			}
+		}
+		series.GeneratedUntil = limit
+		_ = s.repo.UpdateSeries(ctx, series)
+	}
+}
+
+// checkOccurrence reports why a generated occurrence cannot be created.
+func (s *service) checkOccurrence(ctx context.Context, conf *Conference) error {
+	if existing, _ := s.repo.FindByName(ctx, conf.Name); existing != nil {
+		return fmt.Errorf("%w: conference %s already exists", errors.ErrConflict, conf.Name)
+	}
+	if conf.RoomID != "" {
+		if err := s.checkHost(ctx, conf); err != nil {
+			return err
+		}
+		return s.checkRoom(ctx, conf)
+	}
+	return nil
+}
+
+// occurrences builds the conferences of a series that start between its generated horizon and limit.
+func (s *service) occurrences(series *Series, limit time.Time) ([]*Conference, error) {
+	rule, err := ParseRecurrence(series.Rule)
+	if err != nil {
+		return nil, err
+	}
+
+	// Occurrences follow the rule on the local calendar, so a weekly 18:00 meetup stays at 18:00
+	// local time when the clocks change
+	loc := series.Location()
+	var conferences []*Conference
+	for _, start := range rule.Occurrences(series.StartTime.In(loc), limit) {
+		if start.Before(series.GeneratedUntil) {
+			continue
+		}
+		conf := &Conference{
+			Name:               fmt.Sprintf("%s-%s", series.Name, start.Format("2006-01-02")),
+			StartTime:          start.UTC(),
+			EndTime:            start.Add(series.Duration()).UTC(),
+			AvailableSlots:     series.AvailableSlots,
+			Capacity:           series.AvailableSlots,
+			RoomID:             series.RoomID,
+			OwnerID:            series.OwnerID,
+			InviteOnly:         series.InviteOnly,
+			SeriesID:           series.ID,
+			TimeZone:           series.TimeZone,
+			Status:             StatusPublished,
+			CancellationPolicy: series.CancellationPolicy,
+		}
+		conferences = append(conferences, conf)
+	}
+	return conferences, nil
+}
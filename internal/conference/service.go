@@ -1,37 +1,502 @@
 package conference
 
 import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"conference-booking/internal/venue"
 	"conference-booking/pkg/errors"
+	"conference-booking/pkg/query"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("conference-booking/internal/conference")
+
 type Service interface {
-	AddConference(req AddConferenceRequest) error
+	AddConference(ctx context.Context, req AddConferenceRequest) error
+	ListConferences(ctx context.Context, q query.Query) (query.Page[*Conference], error)
+	GetConference(ctx context.Context, name, requesterID string) (*Conference, error)
+	AddSession(ctx context.Context, conferenceName string, req AddSessionRequest, requesterID string) (*Session, error)
+	ListSessions(ctx context.Context, conferenceName, requesterID string) ([]*Session, error)
+	AddTicketType(ctx context.Context, conferenceName string, req AddTicketTypeRequest, requesterID string) (*TicketType, error)
+	ListTicketTypes(ctx context.Context, conferenceName, requesterID string) ([]*TicketType, error)
+	SetCancellationPolicy(ctx context.Context, conferenceName string, policy CancellationPolicy, requesterID string) (*Conference, error)
+	AddPromoCode(ctx context.Context, conferenceName string, req AddPromoCodeRequest, requesterID string) (*PromoCode, error)
+	ListPromoCodes(ctx context.Context, conferenceName, requesterID string) ([]*PromoCode, error)
+	AssignRoom(ctx context.Context, conferenceName string, req AssignRoomRequest, requesterID string) (*Conference, error)
+	SetSeatMap(ctx context.Context, conferenceName string, seatMap SeatMap, requesterID string) (*Conference, error)
+	PublishConference(ctx context.Context, conferenceName, requesterID string) (*Conference, error)
+	CloseRegistration(ctx context.Context, conferenceName, requesterID string) (*Conference, error)
+	AddSeries(ctx context.Context, req AddSeriesRequest) (*Series, error)
+	ListSeries(ctx context.Context) []*Series
+	GetSeries(ctx context.Context, id string) (*Series, error)
+	ListOccurrences(ctx context.Context, id string) ([]*Conference, error)
+	UpdateSeries(ctx context.Context, id string, req UpdateSeriesRequest, requesterID string) (*Series, error)
+	StartSeriesGeneration(interval time.Duration)
+}
+
+// Config holds the deployment settings of the conference service. Rules validates new conferences,
+// series and series edits; deployments build it with NewRuleSet and may add their own rules. A zero
+// SeriesHorizon falls back to that of DefaultConfig.
+type Config struct {
+	Rules         RuleSet
+	SeriesHorizon time.Duration // how far ahead the occurrences of a series are generated
+}
+
+// DefaultConfig is used unless the deployment configures its own.
+var DefaultConfig = Config{
+	Rules:         MustRuleSet(DefaultRuleConfig),
+	SeriesHorizon: 90 * 24 * time.Hour,
 }
 
 type service struct {
-	repo Repository
+	repo  Repository
+	rooms venue.Repository
+	rules RuleSet
+	// seriesHorizon is how far ahead the occurrences of a series are generated.
+	seriesHorizon time.Duration
 }
 
-func NewService(repo Repository) Service {
-	return &service{repo: repo}
+func NewService(repo Repository, rooms venue.Repository, cfg Config) Service {
+	if cfg.SeriesHorizon <= 0 {
+		cfg.SeriesHorizon = DefaultConfig.SeriesHorizon
+	}
+	return &service{repo: repo, rooms: rooms, rules: cfg.Rules, seriesHorizon: cfg.SeriesHorizon}
 }
 
-func (s *service) AddConference(req AddConferenceRequest) error {
-	if req.EndTime.Before(req.StartTime) || req.EndTime.Sub(req.StartTime).Hours() > 12 {
-		return errors.ErrInvalidInput
+func (s *service) AddConference(ctx context.Context, req AddConferenceRequest) error {
+	ctx, span := tracer.Start(ctx, "conference.Service.AddConference", trace.WithAttributes(attribute.String("conference.id", req.Name)))
+	defer span.End()
+
+	start, end, violations := resolveSchedule(req.TimeZone, req.StartTime, req.EndTime, req.LocalStartTime, req.LocalEndTime)
+	if req.CancellationPolicy != nil && !req.CancellationPolicy.Valid() {
+		violations = append(violations, Violation{Field: "cancellation_policy", Message: "needs non-negative days and percentages within 0-100"})
+	}
+	if req.Lottery != nil && !start.IsZero() && !req.Lottery.Valid(start) {
+		violations = append(violations, Violation{Field: "lottery", Message: "registration must open before it closes and close by start_time"})
+	}
+
+	conference := &Conference{
+		Name:           req.Name,
+		StartTime:      start,
+		EndTime:        end,
+		AvailableSlots: req.AvailableSlots,
+		Capacity:       req.AvailableSlots,
+		RoomID:         req.RoomID,
+		OwnerID:        req.OwnerID,
+		InviteOnly:     req.InviteOnly,
+		TimeZone:       req.TimeZone,
+		Status:         StatusDraft,
+
+		CancellationPolicy: req.CancellationPolicy,
+		Lottery:            req.Lottery,
+	}
+	if req.Publish {
+		conference.Status = StatusPublished
+	}
+	violations = append(violations, s.rules.Check(conference, time.Now())...)
+	if len(violations) > 0 {
+		return &ValidationError{Violations: violations}
 	}
 
-	existing, _ := s.repo.FindByName(req.Name)
+	existing, _ := s.repo.FindByName(ctx, req.Name)
 	if existing != nil {
 		return errors.ErrConflict
 	}
+	if conference.RoomID != "" {
+		if err := s.checkHost(ctx, conference); err != nil {
+			return err
+		}
+		if err := s.checkRoom(ctx, conference); err != nil {
+			return err
+		}
+	}
 
-	conference := &Conference{
-		Name:           req.Name,
+	return s.repo.Create(ctx, conference)
+}
+
+// AssignRoom moves a conference into a room, or out of any room with an empty room ID.
+// Only the owner may assign rooms.
+func (s *service) AssignRoom(ctx context.Context, conferenceName string, req AssignRoomRequest, requesterID string) (*Conference, error) {
+	ctx, span := tracer.Start(ctx, "conference.Service.AssignRoom", trace.WithAttributes(attribute.String("conference.id", conferenceName), attribute.String("room.id", req.RoomID)))
+	defer span.End()
+
+	conf, err := s.repo.FindByName(ctx, conferenceName)
+	if err != nil {
+		return nil, err
+	}
+	if conf.OwnerID == "" || conf.OwnerID != requesterID {
+		return nil, errors.ErrForbidden
+	}
+
+	moved := *conf
+	moved.RoomID = req.RoomID
+	if moved.RoomID != "" {
+		if err := s.checkHost(ctx, &moved); err != nil {
+			return nil, err
+		}
+		if err := s.checkRoom(ctx, &moved); err != nil {
+			return nil, err
+		}
+	}
+
+	conf.RoomID = req.RoomID
+	if err := s.repo.Update(ctx, conf); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+// checkHost verifies that the owner of a conference may use its room: they own the venue or were
+// granted it by the venue owner.
+func (s *service) checkHost(ctx context.Context, conf *Conference) error {
+	room, err := s.rooms.FindRoom(ctx, conf.RoomID)
+	if err != nil {
+		return err
+	}
+	site, err := s.rooms.FindVenue(ctx, room.VenueID)
+	if err != nil {
+		return err
+	}
+	if !site.Hosts(conf.OwnerID) {
+		return fmt.Errorf("%w: venue %s has not granted its rooms to %s", errors.ErrForbidden, site.Name, conf.OwnerID)
+	}
+	return nil
+}
+
+// ticketCapacity returns the seats of all ticket types of a conference together.
+func (s *service) ticketCapacity(ctx context.Context, conferenceName string) int {
+	seats := 0
+	for _, ticketType := range s.repo.FindTicketTypes(ctx, conferenceName) {
+		seats += ticketType.Capacity
+	}
+	return seats
+}
+
+// checkRoom verifies that a conference fits into its room and that no other conference uses the
+// room at an overlapping time. Back-to-back conferences are fine.
+func (s *service) checkRoom(ctx context.Context, conf *Conference) error {
+	room, err := s.rooms.FindRoom(ctx, conf.RoomID)
+	if err != nil {
+		return err
+	}
+	if seats := max(conf.Capacity, s.ticketCapacity(ctx, conf.Name)); seats > room.Capacity {
+		return fmt.Errorf("%w: capacity %d exceeds room %s capacity %d", errors.ErrInvalidInput, seats, room.Name, room.Capacity)
+	}
+
+	for _, other := range s.repo.FindByRoom(ctx, room.ID) {
+		if other.Name != conf.Name && other.StartTime.Before(conf.EndTime) && conf.StartTime.Before(other.EndTime) {
+			return fmt.Errorf("%w: room %s is booked by conference %s at an overlapping time", errors.ErrConflict, room.Name, other.Name)
+		}
+	}
+	return nil
+}
+
+func (s *service) ListConferences(ctx context.Context, q query.Query) (query.Page[*Conference], error) {
+	ctx, span := tracer.Start(ctx, "conference.Service.ListConferences")
+	defer span.End()
+
+	return s.repo.List(ctx, q)
+}
+
+// GetConference returns a conference. Drafts are found by their owner only.
+func (s *service) GetConference(ctx context.Context, name, requesterID string) (*Conference, error) {
+	ctx, span := tracer.Start(ctx, "conference.Service.GetConference", trace.WithAttributes(attribute.String("conference.id", name)))
+	defer span.End()
+
+	conf, err := s.repo.FindByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if !conf.VisibleTo(requesterID) {
+		return nil, errors.ErrNotFound
+	}
+	return conf, nil
+}
+
+// PublishConference opens registration for a draft, or reopens it after it was closed. Only the
+// owner may publish, and only before the conference starts.
+func (s *service) PublishConference(ctx context.Context, conferenceName, requesterID string) (*Conference, error) {
+	return s.transition(ctx, "conference.Service.PublishConference", conferenceName, requesterID, StatusPublished, StatusDraft, StatusRegistrationClosed)
+}
+
+// CloseRegistration stops new bookings for a published conference; existing bookings and
+// waitlists stay. Only the owner may close registration.
+func (s *service) CloseRegistration(ctx context.Context, conferenceName, requesterID string) (*Conference, error) {
+	return s.transition(ctx, "conference.Service.CloseRegistration", conferenceName, requesterID, StatusRegistrationClosed, StatusPublished)
+}
+
+// transition moves a conference owned by the requester into the state to, provided its current
+// state is one of from. Cancelling is done by the booking service, which also cancels the bookings.
+func (s *service) transition(ctx context.Context, spanName, conferenceName, requesterID, to string, from ...string) (*Conference, error) {
+	ctx, span := tracer.Start(ctx, spanName, trace.WithAttributes(attribute.String("conference.id", conferenceName)))
+	defer span.End()
+
+	conf, err := s.repo.FindByName(ctx, conferenceName)
+	if err != nil {
+		return nil, err
+	}
+	if conf.OwnerID == "" || conf.OwnerID != requesterID {
+		return nil, errors.ErrForbidden
+	}
+	if state := conf.State(time.Now()); !slices.Contains(from, state) {
+		return nil, fmt.Errorf("%w: conference is %s", errors.ErrInvalidAction, state)
+	}
+
+	conf.Status = to
+	if err := s.repo.Update(ctx, conf); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+// AddSession adds a session to a conference. Only the owner of the conference may add sessions,
+// and the session must fit within the conference time.
+func (s *service) AddSession(ctx context.Context, conferenceName string, req AddSessionRequest, requesterID string) (*Session, error) {
+	ctx, span := tracer.Start(ctx, "conference.Service.AddSession", trace.WithAttributes(attribute.String("conference.id", conferenceName)))
+	defer span.End()
+
+	conf, err := s.repo.FindByName(ctx, conferenceName)
+	if err != nil {
+		return nil, err
+	}
+	if conf.OwnerID == "" || conf.OwnerID != requesterID {
+		return nil, errors.ErrForbidden
+	}
+
+	if req.Title == "" || req.Capacity <= 0 || !req.EndTime.After(req.StartTime) ||
+		req.StartTime.Before(conf.StartTime) || req.EndTime.After(conf.EndTime) {
+		return nil, errors.ErrInvalidInput
+	}
+
+	session := &Session{
+		ID:             uuid.New().String(),
+		ConferenceName: conf.Name,
+		Title:          req.Title,
+		Speaker:        req.Speaker,
+		Track:          req.Track,
+		Room:           req.Room,
 		StartTime:      req.StartTime,
 		EndTime:        req.EndTime,
-		AvailableSlots: req.AvailableSlots,
+		Capacity:       req.Capacity,
+		AvailableSlots: req.Capacity,
+	}
+	if err := s.repo.CreateSession(ctx, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (s *service) ListSessions(ctx context.Context, conferenceName, requesterID string) ([]*Session, error) {
+	ctx, span := tracer.Start(ctx, "conference.Service.ListSessions", trace.WithAttributes(attribute.String("conference.id", conferenceName)))
+	defer span.End()
+
+	if _, err := s.GetConference(ctx, conferenceName, requesterID); err != nil {
+		return nil, err
+	}
+	return s.repo.FindSessions(ctx, conferenceName), nil
+}
+
+// AddTicketType adds a ticket type to a conference. Only the owner of the conference may add ticket types.
+func (s *service) AddTicketType(ctx context.Context, conferenceName string, req AddTicketTypeRequest, requesterID string) (*TicketType, error) {
+	ctx, span := tracer.Start(ctx, "conference.Service.AddTicketType", trace.WithAttributes(attribute.String("conference.id", conferenceName), attribute.String("ticket_type", req.Name)))
+	defer span.End()
+
+	conf, err := s.repo.FindByName(ctx, conferenceName)
+	if err != nil {
+		return nil, err
+	}
+	if conf.OwnerID == "" || conf.OwnerID != requesterID {
+		return nil, errors.ErrForbidden
+	}
+
+	if req.Name == "" || req.Capacity <= 0 || req.Price < 0 || (req.Price > 0 && req.Currency == "") ||
+		(req.SalesStart != nil && req.SalesEnd != nil && !req.SalesEnd.After(*req.SalesStart)) {
+		return nil, errors.ErrInvalidInput
+	}
+	if !ValidTier(req.Tier) {
+		return nil, fmt.Errorf("%w: unknown tier %s", errors.ErrInvalidInput, req.Tier)
+	}
+	// The ticket types share the seats of the conference, and of its room
+	seats := s.ticketCapacity(ctx, conf.Name) + req.Capacity
+	if seats > conf.Capacity {
+		return nil, fmt.Errorf("%w: ticket types would hold %d seats, more than the conference capacity %d", errors.ErrInvalidInput, seats, conf.Capacity)
+	}
+	if conf.RoomID != "" {
+		room, err := s.rooms.FindRoom(ctx, conf.RoomID)
+		if err != nil {
+			return nil, err
+		}
+		if seats > room.Capacity {
+			return nil, fmt.Errorf("%w: ticket types would hold %d seats, more than room %s capacity %d", errors.ErrInvalidInput, seats, room.Name, room.Capacity)
+		}
+	}
+
+	ticketType := &TicketType{
+		ConferenceName: conf.Name,
+		Name:           req.Name,
+		Capacity:       req.Capacity,
+		AvailableSlots: req.Capacity,
+		SalesStart:     req.SalesStart,
+		SalesEnd:       req.SalesEnd,
+		Price:          req.Price,
+		Currency:       req.Currency,
+		Tier:           req.Tier,
+	}
+	if err := s.repo.CreateTicketType(ctx, ticketType); err != nil {
+		return nil, err
+	}
+	return ticketType, nil
+}
+
+func (s *service) ListTicketTypes(ctx context.Context, conferenceName, requesterID string) ([]*TicketType, error) {
+	ctx, span := tracer.Start(ctx, "conference.Service.ListTicketTypes", trace.WithAttributes(attribute.String("conference.id", conferenceName)))
+	defer span.End()
+
+	if _, err := s.GetConference(ctx, conferenceName, requesterID); err != nil {
+		return nil, err
+	}
+	return s.repo.FindTicketTypes(ctx, conferenceName), nil
+}
+
+// SetCancellationPolicy replaces the cancellation policy of a conference. Only the owner may change it;
+// it applies to cancellations from now on, including those of existing bookings.
+func (s *service) SetCancellationPolicy(ctx context.Context, conferenceName string, policy CancellationPolicy, requesterID string) (*Conference, error) {
+	ctx, span := tracer.Start(ctx, "conference.Service.SetCancellationPolicy", trace.WithAttributes(attribute.String("conference.id", conferenceName)))
+	defer span.End()
+
+	conf, err := s.repo.FindByName(ctx, conferenceName)
+	if err != nil {
+		return nil, err
 	}
+	if conf.OwnerID == "" || conf.OwnerID != requesterID {
+		return nil, errors.ErrForbidden
+	}
+	if !policy.Valid() {
+		return nil, errors.ErrInvalidInput
+	}
+
+	conf.CancellationPolicy = &policy
+	if err := s.repo.Update(ctx, conf); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
 
-	return s.repo.Create(conference)
+// SetSeatMap replaces the seat map of a conference. Only the owner may set it, and it must hold at
+// least as many seats as the conference has places. Seats already given to bookings are kept.
+func (s *service) SetSeatMap(ctx context.Context, conferenceName string, seatMap SeatMap, requesterID string) (*Conference, error) {
+	ctx, span := tracer.Start(ctx, "conference.Service.SetSeatMap", trace.WithAttributes(attribute.String("conference.id", conferenceName)))
+	defer span.End()
+
+	conf, err := s.repo.FindByName(ctx, conferenceName)
+	if err != nil {
+		return nil, err
+	}
+	if conf.OwnerID == "" || conf.OwnerID != requesterID {
+		return nil, errors.ErrForbidden
+	}
+	capacity := conf.Capacity
+	if capacity == 0 {
+		capacity = conf.AvailableSlots
+	}
+	if !seatMap.Valid(capacity) {
+		return nil, errors.ErrInvalidInput
+	}
+
+	conf.SeatMap = &seatMap
+	if err := s.repo.Update(ctx, conf); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+// AddPromoCode creates a promotional or invitation code. Only the owner may add codes. Reserved seats
+// are taken out of the free seats of the code's ticket type, or of the conference, right away.
+func (s *service) AddPromoCode(ctx context.Context, conferenceName string, req AddPromoCodeRequest, requesterID string) (*PromoCode, error) {
+	ctx, span := tracer.Start(ctx, "conference.Service.AddPromoCode", trace.WithAttributes(attribute.String("conference.id", conferenceName)))
+	defer span.End()
+
+	conf, err := s.repo.FindByName(ctx, conferenceName)
+	if err != nil {
+		return nil, err
+	}
+	if conf.OwnerID == "" || conf.OwnerID != requesterID {
+		return nil, errors.ErrForbidden
+	}
+
+	if req.Code == "" || req.DiscountPercent < 0 || req.DiscountPercent > 100 || req.MaxUses < 0 || req.ReservedSeats < 0 ||
+		(req.MaxUses > 0 && req.ReservedSeats > req.MaxUses) {
+		return nil, errors.ErrInvalidInput
+	}
+	if !ValidTier(req.Tier) {
+		return nil, fmt.Errorf("%w: unknown tier %s", errors.ErrInvalidInput, req.Tier)
+	}
+	if _, err := s.repo.FindPromoCode(ctx, conf.Name, req.Code); err == nil {
+		return nil, errors.ErrConflict
+	}
+
+	var ticketType *TicketType
+	if req.TicketType != "" {
+		if ticketType, err = s.repo.FindTicketType(ctx, conf.Name, req.TicketType); err != nil {
+			return nil, err
+		}
+	}
+
+	// Set the reserved seats aside
+	if req.ReservedSeats > 0 {
+		if ticketType != nil {
+			if ticketType.AvailableSlots < req.ReservedSeats {
+				return nil, errors.ErrSlotUnavailable
+			}
+			ticketType.AvailableSlots -= req.ReservedSeats
+			err = s.repo.UpdateTicketType(ctx, ticketType)
+		} else {
+			if conf.AvailableSlots < req.ReservedSeats {
+				return nil, errors.ErrSlotUnavailable
+			}
+			conf.AvailableSlots -= req.ReservedSeats
+			err = s.repo.Update(ctx, conf)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	code := &PromoCode{
+		ConferenceName:  conf.Name,
+		Code:            req.Code,
+		TicketType:      req.TicketType,
+		Tier:            req.Tier,
+		DiscountPercent: req.DiscountPercent,
+		MaxUses:         req.MaxUses,
+		ExpiresAt:       req.ExpiresAt,
+		ReservedSeats:   req.ReservedSeats,
+		AvailableSlots:  req.ReservedSeats,
+	}
+	if err := s.repo.CreatePromoCode(ctx, code); err != nil {
+		return nil, err
+	}
+	return code, nil
+}
+
+// ListPromoCodes returns the codes of a conference with their usage. Only the owner may list them.
+func (s *service) ListPromoCodes(ctx context.Context, conferenceName, requesterID string) ([]*PromoCode, error) {
+	ctx, span := tracer.Start(ctx, "conference.Service.ListPromoCodes", trace.WithAttributes(attribute.String("conference.id", conferenceName)))
+	defer span.End()
+
+	conf, err := s.repo.FindByName(ctx, conferenceName)
+	if err != nil {
+		return nil, err
+	}
+	if conf.OwnerID == "" || conf.OwnerID != requesterID {
+		return nil, errors.ErrForbidden
+	}
+	return s.repo.FindPromoCodes(ctx, conf.Name), nil
 }
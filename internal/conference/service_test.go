@@ -42,3 +42,350 @@ package conference
 // 	assert.NoError(t, err)
 // 	// assert.NotNil(t, repo.FindByName(req.Name))
 // }
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"conference-booking/internal/venue"
+	"conference-booking/pkg/errors"
+	"conference-booking/pkg/query"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConferencesMustFitTheirRoom(t *testing.T) {
+	venues := venue.NewInMemoryRepository()
+	service := NewService(NewInMemoryRepository(), venues, DefaultConfig)
+	ctx := context.Background()
+
+	assert.NoError(t, venues.CreateVenue(ctx, &venue.Venue{ID: "expo", Name: "Expo Center", OwnerID: "owner"}))
+	assert.NoError(t, venues.CreateRoom(ctx, &venue.Room{ID: "hall-a", VenueID: "expo", Name: "Hall A", Capacity: 100}))
+	assert.NoError(t, venues.CreateRoom(ctx, &venue.Room{ID: "hall-b", VenueID: "expo", Name: "Hall B", Capacity: 100}))
+
+	start := time.Now().Add(48 * time.Hour).UTC()
+	req := AddConferenceRequest{Name: "TechConf", StartTime: start, EndTime: start.Add(4 * time.Hour), AvailableSlots: 150, RoomID: "hall-a", OwnerID: "owner"}
+
+	// Capacity cannot exceed the room's
+	assert.ErrorIs(t, service.AddConference(ctx, req), errors.ErrInvalidInput)
+	req.AvailableSlots = 100
+	assert.NoError(t, service.AddConference(ctx, req))
+
+	// The room cannot be double-booked, but back-to-back use is fine
+	overlapping := AddConferenceRequest{Name: "DevConf", StartTime: start.Add(2 * time.Hour), EndTime: start.Add(6 * time.Hour), AvailableSlots: 50, RoomID: "hall-a", OwnerID: "owner"}
+	assert.ErrorIs(t, service.AddConference(ctx, overlapping), errors.ErrConflict)
+	overlapping.RoomID = "hall-b"
+	assert.NoError(t, service.AddConference(ctx, overlapping))
+	_, err := service.AssignRoom(ctx, "DevConf", AssignRoomRequest{RoomID: "hall-a"}, "owner")
+	assert.ErrorIs(t, err, errors.ErrConflict)
+
+	backToBack := AddConferenceRequest{Name: "OpsConf", StartTime: start.Add(4 * time.Hour), EndTime: start.Add(6 * time.Hour), AvailableSlots: 50, RoomID: "hall-a", OwnerID: "owner"}
+	assert.NoError(t, service.AddConference(ctx, backToBack))
+
+	// Only the owner moves a conference, and an unknown room is rejected
+	_, err = service.AssignRoom(ctx, "TechConf", AssignRoomRequest{RoomID: "hall-b"}, "someone")
+	assert.ErrorIs(t, err, errors.ErrForbidden)
+	_, err = service.AssignRoom(ctx, "TechConf", AssignRoomRequest{RoomID: "nowhere"}, "owner")
+	assert.ErrorIs(t, err, errors.ErrNotFound)
+	conf, err := service.AssignRoom(ctx, "TechConf", AssignRoomRequest{}, "owner")
+	assert.NoError(t, err)
+	assert.Empty(t, conf.RoomID)
+
+	// Ticket types together cannot outgrow the conference or its room
+	_, err = service.AddTicketType(ctx, "OpsConf", AddTicketTypeRequest{Name: "Standard", Capacity: 40}, "owner")
+	assert.NoError(t, err)
+	_, err = service.AddTicketType(ctx, "OpsConf", AddTicketTypeRequest{Name: "VIP", Capacity: 20}, "owner")
+	assert.ErrorIs(t, err, errors.ErrInvalidInput)
+	_, err = service.AddTicketType(ctx, "OpsConf", AddTicketTypeRequest{Name: "VIP", Capacity: 10, Tier: "gold"}, "owner")
+	assert.ErrorIs(t, err, errors.ErrInvalidInput)
+	ticketType, err := service.AddTicketType(ctx, "OpsConf", AddTicketTypeRequest{Name: "VIP", Capacity: 10, Tier: TierPriority}, "owner")
+	assert.NoError(t, err)
+	assert.Equal(t, TierPriority, ticketType.Tier)
+}
+
+func TestRoomsAreOnlyUsedByTheVenueOwnerOrItsOrganisers(t *testing.T) {
+	venues := venue.NewInMemoryRepository()
+	service := NewService(NewInMemoryRepository(), venues, DefaultConfig)
+	ctx := context.Background()
+
+	assert.NoError(t, venues.CreateVenue(ctx, &venue.Venue{ID: "expo", Name: "Expo Center", OwnerID: "venue-owner"}))
+	assert.NoError(t, venues.CreateRoom(ctx, &venue.Room{ID: "hall-a", VenueID: "expo", Name: "Hall A", Capacity: 100}))
+
+	start := time.Now().Add(48 * time.Hour).UTC()
+	req := AddConferenceRequest{Name: "TechConf", StartTime: start, EndTime: start.Add(4 * time.Hour), AvailableSlots: 50, RoomID: "hall-a", OwnerID: "organiser"}
+	assert.ErrorIs(t, service.AddConference(ctx, req), errors.ErrForbidden)
+	req.RoomID = ""
+	assert.NoError(t, service.AddConference(ctx, req))
+	_, err := service.AssignRoom(ctx, "TechConf", AssignRoomRequest{RoomID: "hall-a"}, "organiser")
+	assert.ErrorIs(t, err, errors.ErrForbidden)
+
+	// Once the venue owner grants the rooms, the organiser may use them
+	_, err = venues.AddOrganiser(ctx, "expo", "organiser")
+	assert.NoError(t, err)
+	conf, err := service.AssignRoom(ctx, "TechConf", AssignRoomRequest{RoomID: "hall-a"}, "organiser")
+	assert.NoError(t, err)
+	assert.Equal(t, "hall-a", conf.RoomID)
+
+	// Taking the grant back keeps the room but stops new placements
+	_, err = venues.RemoveOrganiser(ctx, "expo", "organiser")
+	assert.NoError(t, err)
+	req.Name, req.RoomID = "DevConf", "hall-a"
+	req.StartTime, req.EndTime = start.Add(24*time.Hour), start.Add(28*time.Hour)
+	assert.ErrorIs(t, service.AddConference(ctx, req), errors.ErrForbidden)
+	conf, err = service.GetConference(ctx, "TechConf", "organiser")
+	assert.NoError(t, err)
+	assert.Equal(t, "hall-a", conf.RoomID)
+}
+
+func TestRecurrenceRules(t *testing.T) {
+	start := time.Date(2026, time.January, 31, 18, 0, 0, 0, time.UTC) // a Saturday
+	limit := start.AddDate(1, 0, 0)
+
+	// Weekly on Tuesdays and Thursdays, every other week, counting from start
+	rule, err := ParseRecurrence("RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=TH,TU;COUNT=4")
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Time{
+		time.Date(2026, time.February, 10, 18, 0, 0, 0, time.UTC),
+		time.Date(2026, time.February, 12, 18, 0, 0, 0, time.UTC),
+		time.Date(2026, time.February, 24, 18, 0, 0, 0, time.UTC),
+		time.Date(2026, time.February, 26, 18, 0, 0, 0, time.UTC),
+	}, rule.Occurrences(start, limit))
+
+	// Monthly skips months without the 31st; UNTIL is inclusive
+	rule, err = ParseRecurrence("FREQ=MONTHLY;UNTIL=20260531")
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Time{start, start.AddDate(0, 2, 0), start.AddDate(0, 4, 0)}, rule.Occurrences(start, limit))
+
+	// A day listed twice is one occurrence
+	rule, err = ParseRecurrence("FREQ=WEEKLY;BYDAY=TU,TU;COUNT=2")
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Time{
+		time.Date(2026, time.February, 3, 18, 0, 0, 0, time.UTC),
+		time.Date(2026, time.February, 10, 18, 0, 0, 0, time.UTC),
+	}, rule.Occurrences(start, limit))
+
+	// The limit cuts open-ended rules
+	rule, err = ParseRecurrence("FREQ=DAILY")
+	assert.NoError(t, err)
+	assert.Len(t, rule.Occurrences(start, start.AddDate(0, 0, 10)), 10)
+
+	for _, bad := range []string{"", "FREQ=YEARLY", "FREQ=DAILY;BYDAY=MO", "FREQ=WEEKLY;BYDAY=XX", "FREQ=DAILY;COUNT=0", "FREQ=DAILY;COUNT=2;UNTIL=20260601", "FREQ=DAILY;BYHOUR=9"} {
+		_, err := ParseRecurrence(bad)
+		assert.ErrorIs(t, err, errors.ErrInvalidInput, bad)
+	}
+}
+
+func TestSeriesEditsPropagateToFutureOccurrences(t *testing.T) {
+	repo := NewInMemoryRepository()
+	// A weekly meetup that started two weeks ago, which the default rules would reject
+	svc := NewService(repo, venue.NewInMemoryRepository(), Config{Rules: MustRuleSet(RuleConfig{MaxDuration: 12 * time.Hour})}).(*service)
+	ctx := context.Background()
+
+	start := time.Now().Add(-14*24*time.Hour + time.Hour).UTC().Truncate(time.Second)
+	series, err := svc.AddSeries(ctx, AddSeriesRequest{Name: "GoMeetup", Rule: "FREQ=WEEKLY", StartTime: start, EndTime: start.Add(2 * time.Hour), AvailableSlots: 30, OwnerID: "owner"})
+	assert.NoError(t, err)
+	occurrences, err := svc.ListOccurrences(ctx, series.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "GoMeetup-"+start.Format("2006-01-02"), occurrences[0].Name)
+	assert.Len(t, occurrences, 15) // two past weeks and the 90-day horizon
+
+	// Seats are taken in the next occurrence
+	next := occurrences[2]
+	next.AvailableSlots = 5
+
+	// Only the owner edits, and not below the seats already taken
+	_, err = svc.UpdateSeries(ctx, series.ID, UpdateSeriesRequest{}, "someone")
+	assert.ErrorIs(t, err, errors.ErrForbidden)
+	slots := 20
+	_, err = svc.UpdateSeries(ctx, series.ID, UpdateSeriesRequest{AvailableSlots: &slots}, "owner")
+	assert.ErrorIs(t, err, errors.ErrConflict)
+
+	slots, minutes := 40, 90
+	series, err = svc.UpdateSeries(ctx, series.ID, UpdateSeriesRequest{AvailableSlots: &slots, DurationMinutes: &minutes}, "owner")
+	assert.NoError(t, err)
+	assert.Equal(t, 40, series.AvailableSlots)
+
+	// Past occurrences keep their details; future ones keep their bookings
+	assert.Equal(t, 30, occurrences[0].Capacity)
+	assert.Equal(t, 2*time.Hour, occurrences[0].EndTime.Sub(occurrences[0].StartTime))
+	assert.Equal(t, 40, next.Capacity)
+	assert.Equal(t, 15, next.AvailableSlots)
+	assert.Equal(t, 90*time.Minute, next.EndTime.Sub(next.StartTime))
+
+	// Moving the horizon generates the next occurrences with the edited details, skipping one whose
+	// name is taken without holding up the rest
+	taken := occurrences[len(occurrences)-1].StartTime.AddDate(0, 0, 7)
+	assert.NoError(t, repo.Create(ctx, &Conference{Name: "GoMeetup-" + taken.Format("2006-01-02"), StartTime: taken, EndTime: taken.Add(time.Hour)}))
+	svc.generateOccurrences(ctx, time.Now().Add(14*24*time.Hour))
+	extended, err := svc.ListOccurrences(ctx, series.ID)
+	assert.NoError(t, err)
+	assert.Len(t, extended, len(occurrences)+1)
+	assert.Equal(t, 40, extended[len(extended)-1].AvailableSlots)
+	assert.Equal(t, 90*time.Minute, extended[len(extended)-1].EndTime.Sub(extended[len(extended)-1].StartTime))
+	assert.Len(t, series.Skipped, 1)
+	assert.Equal(t, taken, series.Skipped[0].StartTime)
+	assert.True(t, series.GeneratedUntil.After(taken))
+
+	// A series whose first occurrences cannot all be created is not created at all
+	first := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+	third := first.AddDate(0, 0, 2)
+	assert.NoError(t, repo.Create(ctx, &Conference{Name: "Workshop-" + third.Format("2006-01-02"), StartTime: third, EndTime: third.Add(time.Hour)}))
+	_, err = svc.AddSeries(ctx, AddSeriesRequest{Name: "Workshop", Rule: "FREQ=DAILY;COUNT=3", StartTime: first, EndTime: first.Add(time.Hour), AvailableSlots: 5, OwnerID: "owner"})
+	assert.ErrorIs(t, err, errors.ErrConflict)
+	_, err = repo.FindByName(ctx, "Workshop-"+first.Format("2006-01-02"))
+	assert.ErrorIs(t, err, errors.ErrNotFound)
+	assert.Len(t, svc.ListSeries(ctx), 1)
+}
+
+func TestConferenceTimeZones(t *testing.T) {
+	repo := NewInMemoryRepository()
+	// The DST dates below are fixed, so allow creating conferences in the past
+	svc := NewService(repo, venue.NewInMemoryRepository(), Config{Rules: MustRuleSet(RuleConfig{MaxDuration: 12 * time.Hour})})
+	ctx := context.Background()
+
+	// Clocks in Berlin go back an hour at 03:00 on 25 October 2026, so 00:00-12:00 local lasts 13
+	// hours but counts as 12 on the wall clock
+	req := AddConferenceRequest{Name: "BerlinConf", TimeZone: "Europe/Berlin", LocalStartTime: "2026-10-24T23:00", LocalEndTime: "2026-10-25T12:00", AvailableSlots: 10}
+	assert.ErrorIs(t, svc.AddConference(ctx, req), errors.ErrInvalidInput)
+	req.LocalStartTime = "2026-10-25T00:00"
+	assert.NoError(t, svc.AddConference(ctx, req))
+	conf, err := repo.FindByName(ctx, "BerlinConf")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2026, time.October, 24, 22, 0, 0, 0, time.UTC), conf.StartTime)
+	assert.Equal(t, 13*time.Hour, conf.EndTime.Sub(conf.StartTime))
+
+	// Responses carry both UTC and local times
+	body, err := json.Marshal(conf)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `"start_time":"2026-10-24T22:00:00Z"`)
+	assert.Contains(t, string(body), `"local_start_time":"2026-10-25T00:00:00+02:00"`)
+	assert.Contains(t, string(body), `"local_end_time":"2026-10-25T12:00:00+01:00"`)
+
+	// Skipped local times do not exist; repeated ones mean the first
+	req = AddConferenceRequest{Name: "NightConf", TimeZone: "Europe/Berlin", LocalStartTime: "2026-03-29T02:30", LocalEndTime: "2026-03-29T05:00"}
+	assert.ErrorIs(t, svc.AddConference(ctx, req), errors.ErrInvalidInput)
+	req.LocalStartTime, req.LocalEndTime = "2026-10-25T02:30", "2026-10-25T05:00"
+	assert.NoError(t, svc.AddConference(ctx, req))
+	conf, err = repo.FindByName(ctx, "NightConf")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(2026, time.October, 25, 0, 30, 0, 0, time.UTC), conf.StartTime)
+
+	req = AddConferenceRequest{Name: "MoonConf", TimeZone: "Moon/Tranquility", StartTime: time.Now(), EndTime: time.Now().Add(time.Hour)}
+	assert.ErrorIs(t, svc.AddConference(ctx, req), errors.ErrInvalidInput)
+
+	// A weekly series keeps its local time of day across the change
+	series, err := svc.AddSeries(ctx, AddSeriesRequest{Name: "BerlinMeetup", Rule: "FREQ=WEEKLY;COUNT=3", TimeZone: "Europe/Berlin", LocalStartTime: "2026-10-18T18:00", LocalEndTime: "2026-10-18T20:00", AvailableSlots: 10, OwnerID: "owner"})
+	assert.NoError(t, err)
+	occurrences, err := svc.ListOccurrences(ctx, series.ID)
+	assert.NoError(t, err)
+	assert.Len(t, occurrences, 3)
+	assert.Equal(t, time.Date(2026, time.October, 18, 16, 0, 0, 0, time.UTC), occurrences[0].StartTime)
+	assert.Equal(t, time.Date(2026, time.October, 25, 17, 0, 0, 0, time.UTC), occurrences[1].StartTime)
+	assert.Equal(t, "BerlinMeetup-2026-10-25", occurrences[1].Name)
+	assert.Equal(t, "Europe/Berlin", occurrences[1].TimeZone)
+}
+
+func TestValidationRulesReportEveryViolation(t *testing.T) {
+	svc := NewService(NewInMemoryRepository(), venue.NewInMemoryRepository(), DefaultConfig)
+	ctx := context.Background()
+	fields := func(err error) []string {
+		var invalid *ValidationError
+		if !assert.ErrorAs(t, err, &invalid) {
+			return nil
+		}
+		var fields []string
+		for _, v := range invalid.Violations {
+			fields = append(fields, v.Field)
+		}
+		return fields
+	}
+
+	// The default rules reject blank names, no seats and starts in the past, all at once
+	past := time.Now().Add(-time.Hour)
+	err := svc.AddConference(ctx, AddConferenceRequest{Name: " ", StartTime: past, EndTime: past.Add(13 * time.Hour)})
+	assert.ErrorIs(t, err, errors.ErrInvalidInput)
+	assert.ElementsMatch(t, []string{"name", "available_slots", "start_time", "end_time"}, fields(err))
+	err = svc.AddConference(ctx, AddConferenceRequest{Name: "TechConf", LocalStartTime: "tomorrow", AvailableSlots: -1})
+	assert.ElementsMatch(t, []string{"local_start_time", "end_time", "available_slots"}, fields(err))
+
+	// Deployments configure the built-in rules and add their own
+	rules, err := NewRuleSet(RuleConfig{MaxDuration: 2 * time.Hour, MinLeadTime: 48 * time.Hour, MinCapacity: 10, MaxCapacity: 100, NamePattern: `[A-Z][A-Za-z]+`})
+	assert.NoError(t, err)
+	svc = NewService(NewInMemoryRepository(), venue.NewInMemoryRepository(), Config{Rules: append(rules, RuleFunc(func(conf *Conference, now time.Time) []Violation {
+		if conf.RoomID == "" {
+			return []Violation{{Field: "room_id", Message: "is required"}}
+		}
+		return nil
+	}))})
+
+	soon := time.Now().Add(24 * time.Hour)
+	err = svc.AddConference(ctx, AddConferenceRequest{Name: "techconf 2026", StartTime: soon, EndTime: soon.Add(3 * time.Hour), AvailableSlots: 500})
+	assert.ElementsMatch(t, []string{"name", "start_time", "end_time", "available_slots", "room_id"}, fields(err))
+	assert.Contains(t, err.Error(), "start_time must be at least 48h0m0s ahead")
+
+	_, err = svc.AddSeries(ctx, AddSeriesRequest{Name: "Meetup", Rule: "FREQ=HOURLY", StartTime: soon, EndTime: soon.Add(time.Hour), AvailableSlots: 5})
+	assert.ElementsMatch(t, []string{"rule", "start_time", "available_slots", "room_id"}, fields(err))
+
+	_, err = NewRuleSet(RuleConfig{NamePattern: "("})
+	assert.Error(t, err)
+	_, err = NewRuleSet(RuleConfig{MinCapacity: 10, MaxCapacity: 5})
+	assert.Error(t, err)
+}
+
+func TestConferenceLifecycle(t *testing.T) {
+	svc := NewService(NewInMemoryRepository(), venue.NewInMemoryRepository(), DefaultConfig)
+	ctx := context.Background()
+	start := time.Now().Add(24 * time.Hour)
+
+	// New conferences are drafts, visible only to their owner
+	assert.NoError(t, svc.AddConference(ctx, AddConferenceRequest{Name: "TechConf", StartTime: start, EndTime: start.Add(2 * time.Hour), AvailableSlots: 10, OwnerID: "owner"}))
+	_, err := svc.GetConference(ctx, "TechConf", "someone")
+	assert.ErrorIs(t, err, errors.ErrNotFound)
+	_, err = svc.ListTicketTypes(ctx, "TechConf", "")
+	assert.ErrorIs(t, err, errors.ErrNotFound)
+	_, err = svc.GetConference(ctx, "TechConf", "owner")
+	assert.NoError(t, err)
+	_, err = svc.ListSessions(ctx, "TechConf", "owner")
+	assert.NoError(t, err)
+	page, err := svc.ListConferences(ctx, query.Query{})
+	assert.NoError(t, err)
+	assert.Empty(t, page.Items)
+	page, err = svc.ListConferences(ctx, query.Query{UserID: "owner", Status: StatusDraft})
+	assert.NoError(t, err)
+	assert.Len(t, page.Items, 1)
+
+	// Only the owner moves it through the states, and only along allowed transitions
+	_, err = svc.PublishConference(ctx, "TechConf", "someone")
+	assert.ErrorIs(t, err, errors.ErrForbidden)
+	_, err = svc.CloseRegistration(ctx, "TechConf", "owner")
+	assert.ErrorIs(t, err, errors.ErrInvalidAction)
+	conf, err := svc.PublishConference(ctx, "TechConf", "owner")
+	assert.NoError(t, err)
+	assert.True(t, conf.RegistrationOpen(time.Now()))
+	conf, err = svc.CloseRegistration(ctx, "TechConf", "owner")
+	assert.NoError(t, err)
+	assert.Equal(t, StatusRegistrationClosed, conf.State(time.Now()))
+	assert.False(t, conf.RegistrationOpen(time.Now()))
+	_, err = svc.PublishConference(ctx, "TechConf", "owner")
+	assert.NoError(t, err)
+
+	// The schedule drives the later states
+	assert.Equal(t, StatusInProgress, conf.State(start.Add(time.Hour)))
+	assert.Equal(t, StatusCompleted, conf.State(start.Add(3*time.Hour)))
+	_, err = svc.CloseRegistration(ctx, "TechConf", "owner")
+	assert.NoError(t, err)
+	conf.StartTime = time.Now().Add(-time.Hour)
+	_, err = svc.PublishConference(ctx, "TechConf", "owner")
+	assert.ErrorIs(t, err, errors.ErrInvalidAction)
+
+	// Conferences created with publish are visible right away
+	assert.NoError(t, svc.AddConference(ctx, AddConferenceRequest{Name: "OpenConf", StartTime: start, EndTime: start.Add(time.Hour), AvailableSlots: 10, Publish: true}))
+	page, err = svc.ListConferences(ctx, query.Query{Status: StatusPublished})
+	assert.NoError(t, err)
+	assert.Len(t, page.Items, 1)
+	body, err := json.Marshal(page.Items[0])
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `"status":"published"`)
+}
@@ -0,0 +1,125 @@
+package conference
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+	_ "time/tzdata" // IANA zones must resolve even on hosts without a zoneinfo database
+
+	"conference-booking/pkg/errors"
+)
+
+// LocalLayout is the format of local_start_time and local_end_time: a wall-clock time without offset.
+const LocalLayout = "2006-01-02T15:04"
+
+// loadLocation resolves an IANA time zone name; the empty name means UTC.
+func loadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil || name == "Local" {
+		return nil, fmt.Errorf("%w: unknown time zone %q", errors.ErrInvalidInput, name)
+	}
+	return loc, nil
+}
+
+// Location returns the time zone of the conference, UTC when none is set.
+func (c *Conference) Location() *time.Location {
+	if loc, err := loadLocation(c.TimeZone); err == nil {
+		return loc
+	}
+	return time.UTC
+}
+
+// Location returns the time zone of the series, UTC when none is set.
+func (s *Series) Location() *time.Location {
+	if loc, err := loadLocation(s.TimeZone); err == nil {
+		return loc
+	}
+	return time.UTC
+}
+
+// MarshalJSON renders start and end in UTC and, as local_start_time and local_end_time, in the
+// conference's time zone. The status is the current lifecycle state.
+func (c Conference) MarshalJSON() ([]byte, error) {
+	type plain Conference
+	loc := c.Location()
+	return json.Marshal(struct {
+		plain
+		StartTime      time.Time `json:"start_time"`
+		EndTime        time.Time `json:"end_time"`
+		LocalStartTime time.Time `json:"local_start_time"`
+		LocalEndTime   time.Time `json:"local_end_time"`
+		Status         string    `json:"status"`
+	}{plain(c), c.StartTime.UTC(), c.EndTime.UTC(), c.StartTime.In(loc), c.EndTime.In(loc), c.State(time.Now())})
+}
+
+// MarshalJSON renders the first occurrence in UTC and in the series' time zone, like Conference.
+func (s Series) MarshalJSON() ([]byte, error) {
+	type plain Series
+	loc := s.Location()
+	return json.Marshal(struct {
+		plain
+		StartTime      time.Time `json:"start_time"`
+		EndTime        time.Time `json:"end_time"`
+		LocalStartTime time.Time `json:"local_start_time"`
+		LocalEndTime   time.Time `json:"local_end_time"`
+	}{plain(s), s.StartTime.UTC(), s.EndTime.UTC(), s.StartTime.In(loc), s.EndTime.In(loc)})
+}
+
+// resolveSchedule returns the start and end instants of a request in the given time zone. Times
+// that cannot be resolved are reported as violations and returned as zero.
+func resolveSchedule(zone string, start, end time.Time, localStart, localEnd string) (time.Time, time.Time, []Violation) {
+	loc, err := loadLocation(zone)
+	if err != nil {
+		return time.Time{}, time.Time{}, []Violation{{Field: "time_zone", Message: "must be an IANA time zone such as Europe/Berlin"}}
+	}
+
+	var violations []Violation
+	start, violation := resolveTime("start_time", start, localStart, loc)
+	if violation != nil {
+		violations = append(violations, *violation)
+	}
+	end, violation = resolveTime("end_time", end, localEnd, loc)
+	if violation != nil {
+		violations = append(violations, *violation)
+	}
+	return start, end, violations
+}
+
+// resolveTime returns the instant of a request time, given either as an instant or as a local
+// wall-clock time in loc. Local times skipped by a DST change do not exist and are rejected; local
+// times repeated by one mean their first occurrence.
+func resolveTime(field string, instant time.Time, local string, loc *time.Location) (time.Time, *Violation) {
+	if local == "" {
+		if instant.IsZero() {
+			return time.Time{}, &Violation{Field: field, Message: "or local_" + field + " is required"}
+		}
+		return instant.UTC(), nil
+	}
+
+	t, err := time.ParseInLocation(LocalLayout, local, loc)
+	if err != nil {
+		return time.Time{}, &Violation{Field: "local_" + field, Message: "must look like 2026-11-03T18:00"}
+	}
+	if t.Format(LocalLayout) != local {
+		return time.Time{}, &Violation{Field: "local_" + field, Message: fmt.Sprintf("%s does not exist in %s", local, loc)}
+	}
+	_, offset := t.Zone()
+	if _, before := t.Add(-3 * time.Hour).Zone(); before > offset {
+		if earlier := t.Add(-time.Duration(before-offset) * time.Second); earlier.Format(LocalLayout) == local {
+			t = earlier
+		}
+	}
+	return t.UTC(), nil
+}
+
+// wallClock returns the local date and time of t in loc as if they were UTC, so that differences
+// between wall-clock times ignore DST changes.
+func wallClock(t time.Time, loc *time.Location) time.Time {
+	local := t.In(loc)
+	year, month, day := local.Date()
+	hour, minute, second := local.Clock()
+	return time.Date(year, month, day, hour, minute, second, local.Nanosecond(), time.UTC)
+}
@@ -0,0 +1,69 @@
+package importer
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+
+	"conference-booking/internal/booking"
+	"conference-booking/internal/conference"
+	"conference-booking/internal/notification"
+	"conference-booking/internal/payment"
+	"conference-booking/internal/user"
+	"conference-booking/pkg/checkin"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(router *gin.Engine, confRepo conference.Repository, userRepo user.Repository, bookingRepo booking.Repository, payments payment.Provider, notifier notification.Notifier, signer *checkin.Signer, cfg booking.Config) {
+	h := NewHandler(confRepo, userRepo, bookingRepo, payments, notifier, signer, cfg)
+	group := router.Group("/import")
+	{
+		group.POST("/users", h.ImportUsers)
+		group.POST("/bookings", h.ImportBookings)
+	}
+}
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(confRepo conference.Repository, userRepo user.Repository, bookingRepo booking.Repository, payments payment.Provider, notifier notification.Notifier, signer *checkin.Signer, cfg booking.Config) *Handler {
+	return &Handler{
+		service: NewService(confRepo, userRepo, bookingRepo, payments, notifier, signer, cfg),
+	}
+}
+
+func (h *Handler) ImportUsers(c *gin.Context) {
+	h.handleImport(c, h.service.ImportUsers)
+}
+
+func (h *Handler) ImportBookings(c *gin.Context) {
+	h.handleImport(c, h.service.ImportBookings)
+}
+
+// handleImport reads the raw request body as CSV (text/csv) or JSONL (application/x-ndjson).
+// ?dry_run=true validates every row without applying anything.
+func (h *Handler) handleImport(c *gin.Context, importFn func(context.Context, io.Reader, Options) (*Report, error)) {
+	opts := Options{Format: FormatCSV}
+	if c.ContentType() == "application/x-ndjson" {
+		opts.Format = FormatJSONL
+	}
+	if raw := c.Query("dry_run"); raw != "" {
+		dryRun, err := strconv.ParseBool(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		opts.DryRun = dryRun
+	}
+
+	report, err := importFn(c.Request.Context(), c.Request.Body, opts)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,51 @@
+package importer
+
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+
+	StatusValid    = "valid"
+	StatusImported = "imported"
+	StatusFailed   = "failed"
+)
+
+// RowResult is the outcome of a single input row. Line is 1-based and counts
+// the CSV header, so it matches what a spreadsheet shows.
+type RowResult struct {
+	Line   int    `json:"line"`
+	Key    string `json:"key,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	ID     string `json:"id,omitempty"`
+}
+
+// Report summarises an import run. Batches counts the batches of rows that were processed.
+type Report struct {
+	DryRun    bool         `json:"dry_run"`
+	Total     int          `json:"total"`
+	Succeeded int          `json:"succeeded"`
+	Failed    int          `json:"failed"`
+	Batches   int          `json:"batches"`
+	Rows      []*RowResult `json:"rows"`
+}
+
+type Options struct {
+	Format string
+	DryRun bool
+}
+
+type row[T any] struct {
+	line int
+	req  T
+	err  error
+}
+
+func (r *Report) add(result *RowResult) {
+	r.Total++
+	if result.Status == StatusFailed {
+		r.Failed++
+	} else {
+		r.Succeeded++
+	}
+	r.Rows = append(r.Rows, result)
+}
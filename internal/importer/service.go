@@ -0,0 +1,248 @@
+package importer
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"conference-booking/internal/booking"
+	"conference-booking/internal/conference"
+	"conference-booking/internal/notification"
+	"conference-booking/internal/payment"
+	"conference-booking/internal/user"
+	"conference-booking/pkg/checkin"
+	apperrors "conference-booking/pkg/errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("conference-booking/internal/importer")
+
+// BatchSize is the number of rows validated and then applied together.
+const BatchSize = 100
+
+type Service interface {
+	ImportUsers(ctx context.Context, r io.Reader, opts Options) (*Report, error)
+	ImportBookings(ctx context.Context, r io.Reader, opts Options) (*Report, error)
+}
+
+type service struct {
+	confRepo       conference.Repository
+	userRepo       user.Repository
+	bookingRepo    booking.Repository
+	userService    user.Service
+	bookingService booking.Service
+}
+
+func NewService(confRepo conference.Repository, userRepo user.Repository, bookingRepo booking.Repository, payments payment.Provider, notifier notification.Notifier, signer *checkin.Signer, cfg booking.Config) Service {
+	return &service{
+		confRepo:       confRepo,
+		userRepo:       userRepo,
+		bookingRepo:    bookingRepo,
+		userService:    user.NewService(userRepo),
+		bookingService: booking.NewService(confRepo, userRepo, bookingRepo, payments, notifier, signer, cfg),
+	}
+}
+
+func (s *service) ImportUsers(ctx context.Context, r io.Reader, opts Options) (*Report, error) {
+	ctx, span := tracer.Start(ctx, "importer.Service.ImportUsers", trace.WithAttributes(attribute.Bool("import.dry_run", opts.DryRun)))
+	defer span.End()
+
+	rows, err := decode(r, opts.Format, []string{"id"}, func(fields map[string]string) user.AddUserRequest {
+		return user.AddUserRequest{ID: fields["id"], Name: fields["name"], Email: fields["email"], Tier: fields["tier"]}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	validate := func(req user.AddUserRequest) (string, error) {
+		if req.ID == "" {
+			return "", fmt.Errorf("%w: id is required", apperrors.ErrInvalidInput)
+		}
+		if !conference.ValidTier(req.Tier) {
+			return req.ID, fmt.Errorf("%w: unknown tier %s", apperrors.ErrInvalidInput, req.Tier)
+		}
+		if seen[req.ID] {
+			return req.ID, fmt.Errorf("%w: duplicate id in file", apperrors.ErrConflict)
+		}
+		seen[req.ID] = true
+		if _, err := s.userRepo.FindByID(ctx, req.ID); err == nil {
+			return req.ID, fmt.Errorf("%w: user already exists", apperrors.ErrConflict)
+		}
+		return req.ID, nil
+	}
+	apply := func(req user.AddUserRequest) (string, error) {
+		return req.ID, s.userService.AddUser(ctx, req)
+	}
+
+	return run(ctx, rows, opts.DryRun, validate, apply)
+}
+
+func (s *service) ImportBookings(ctx context.Context, r io.Reader, opts Options) (*Report, error) {
+	ctx, span := tracer.Start(ctx, "importer.Service.ImportBookings", trace.WithAttributes(attribute.Bool("import.dry_run", opts.DryRun)))
+	defer span.End()
+
+	rows, err := decode(r, opts.Format, []string{"conference_name", "user_id"}, func(fields map[string]string) booking.BookConferenceRequest {
+		return booking.BookConferenceRequest{ConferenceName: fields["conference_name"], UserID: fields["user_id"]}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	validate := func(req booking.BookConferenceRequest) (string, error) {
+		key := req.ConferenceName + "/" + req.UserID
+		if req.ConferenceName == "" || req.UserID == "" {
+			return key, fmt.Errorf("%w: conference_name and user_id are required", apperrors.ErrInvalidInput)
+		}
+		if seen[key] {
+			return key, fmt.Errorf("%w: duplicate booking in file", apperrors.ErrConflict)
+		}
+		seen[key] = true
+		if _, err := s.confRepo.FindByName(ctx, req.ConferenceName); err != nil {
+			return key, fmt.Errorf("conference %q: %w", req.ConferenceName, err)
+		}
+		if _, err := s.userRepo.FindByID(ctx, req.UserID); err != nil {
+			return key, fmt.Errorf("user %q: %w", req.UserID, err)
+		}
+		if existing, err := s.bookingRepo.FindActiveBooking(ctx, req.UserID, req.ConferenceName, ""); err == nil {
+			return key, fmt.Errorf("%w: user already has an active booking with ID: %s", apperrors.ErrConflict, existing.ID)
+		}
+		return key, nil
+	}
+	apply := func(req booking.BookConferenceRequest) (string, error) {
+		return s.bookingService.BookConference(ctx, req)
+	}
+
+	return run(ctx, rows, opts.DryRun, validate, apply)
+}
+
+// run works through the rows in batches of BatchSize. Every row of a batch is validated before
+// any of it is applied, and the valid ones are then applied unless dryRun is set. Cancellation is
+// checked between batches, so an interrupted import stops at a batch boundary and its report
+// covers exactly the batches that were processed.
+func run[T any](ctx context.Context, rows []row[T], dryRun bool,
+	validate func(T) (string, error), apply func(T) (string, error)) (*Report, error) {
+	report := &Report{DryRun: dryRun, Rows: []*RowResult{}}
+
+	for start := 0; start < len(rows); start += BatchSize {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+		batch := rows[start:min(start+BatchSize, len(rows))]
+
+		results := make([]*RowResult, len(batch))
+		for i, r := range batch {
+			results[i] = &RowResult{Line: r.line, Status: StatusValid}
+			err := r.err
+			if err == nil {
+				results[i].Key, err = validate(r.req)
+			}
+			if err != nil {
+				results[i].Status = StatusFailed
+				results[i].Error = err.Error()
+			}
+		}
+
+		for i, r := range batch {
+			if dryRun || results[i].Status != StatusValid {
+				continue
+			}
+			id, err := apply(r.req)
+			if err != nil {
+				results[i].Status = StatusFailed
+				results[i].Error = err.Error()
+				continue
+			}
+			results[i].Status = StatusImported
+			results[i].ID = id
+		}
+
+		for _, result := range results {
+			report.add(result)
+		}
+		report.Batches++
+	}
+	return report, nil
+}
+
+// decode reads CSV (with a header row) or JSONL input into requests.
+// Malformed rows are kept with their error so they show up in the report.
+func decode[T any](r io.Reader, format string, columns []string, fromFields func(map[string]string) T) ([]row[T], error) {
+	var rows []row[T]
+
+	switch format {
+	case FormatCSV:
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+
+		header, err := reader.Read()
+		if err != nil {
+			return nil, fmt.Errorf("%w: missing CSV header", apperrors.ErrInvalidInput)
+		}
+		index := make(map[string]int)
+		for i, name := range header {
+			index[strings.TrimSpace(name)] = i
+		}
+		for _, column := range columns {
+			if _, ok := index[column]; !ok {
+				return nil, fmt.Errorf("%w: missing CSV column %q", apperrors.ErrInvalidInput, column)
+			}
+		}
+
+		for {
+			record, err := reader.Read()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				rows = append(rows, row[T]{line: parseErr.Line, err: fmt.Errorf("%w: %v", apperrors.ErrInvalidInput, parseErr.Err)})
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			line, _ := reader.FieldPos(0)
+			fields := make(map[string]string)
+			for column, i := range index {
+				if i < len(record) {
+					fields[column] = strings.TrimSpace(record[i])
+				}
+			}
+			rows = append(rows, row[T]{line: line, req: fromFields(fields)})
+		}
+
+	case FormatJSONL:
+		scanner := bufio.NewScanner(r)
+		for line := 1; scanner.Scan(); line++ {
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" {
+				continue
+			}
+			var req T
+			if err := json.Unmarshal([]byte(text), &req); err != nil {
+				rows = append(rows, row[T]{line: line, err: fmt.Errorf("%w: %v", apperrors.ErrInvalidInput, err)})
+				continue
+			}
+			rows = append(rows, row[T]{line: line, req: req})
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, fmt.Errorf("%w: unsupported format %q", apperrors.ErrInvalidInput, format)
+	}
+
+	return rows, nil
+}
@@ -0,0 +1,103 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"conference-booking/internal/booking"
+	"conference-booking/internal/conference"
+	"conference-booking/internal/notification"
+	"conference-booking/internal/payment"
+	"conference-booking/internal/user"
+	"conference-booking/pkg/checkin"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setupImportService() (Service, user.Repository) {
+	confRepo := conference.NewInMemoryRepository()
+	userRepo := user.NewInMemoryRepository()
+	bookingRepo := booking.NewInMemoryRepository(confRepo)
+	signer, _ := checkin.NewSigner("test-secret")
+	return NewService(confRepo, userRepo, bookingRepo, payment.NewFakeProvider(), notification.NewLogNotifier(), signer, booking.Config{}), userRepo
+}
+
+func TestImportUsersDryRunThenApply(t *testing.T) {
+	service, userRepo := setupImportService()
+	ctx := context.Background()
+	input := "id\nuser1\n\nuser2\nuser1\n"
+
+	// Dry run reports the duplicate without creating anyone
+	report, err := service.ImportUsers(ctx, strings.NewReader(input), Options{Format: FormatCSV, DryRun: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, report.Total)
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, StatusFailed, report.Rows[2].Status)
+	assert.Equal(t, 5, report.Rows[2].Line)
+	_, err = userRepo.FindByID(ctx, "user1")
+	assert.Error(t, err)
+
+	// Applying creates the valid rows
+	report, err = service.ImportUsers(ctx, strings.NewReader(input), Options{Format: FormatCSV})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.Succeeded)
+	assert.Equal(t, StatusImported, report.Rows[0].Status)
+	_, err = userRepo.FindByID(ctx, "user2")
+	assert.NoError(t, err)
+}
+
+func TestImportUsersReadsOptionalColumns(t *testing.T) {
+	service, userRepo := setupImportService()
+	ctx := context.Background()
+
+	report, err := service.ImportUsers(ctx, strings.NewReader("email,id,name,tier\nada@example.com,ada,Ada Lovelace,member\n,bob,,gold\n"), Options{Format: FormatCSV})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Succeeded)
+	u, err := userRepo.FindByID(ctx, "ada")
+	assert.NoError(t, err)
+	assert.Equal(t, "Ada Lovelace", u.Name)
+	assert.Equal(t, "ada@example.com", u.Email)
+	assert.Equal(t, conference.TierMember, u.Tier)
+
+	// An unknown tier rejects the row
+	assert.Equal(t, 1, report.Failed)
+	_, err = userRepo.FindByID(ctx, "bob")
+	assert.Error(t, err)
+}
+
+func TestImportBookingsReportsUnknownUser(t *testing.T) {
+	service, _ := setupImportService()
+	ctx := context.Background()
+	input := `{"conference_name": "TechConf", "user_id": "ghost"}` + "\nnot json\n"
+
+	report, err := service.ImportBookings(ctx, strings.NewReader(input), Options{Format: FormatJSONL, DryRun: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.Failed)
+	assert.Contains(t, report.Rows[0].Error, "resource not found")
+	assert.Equal(t, 2, report.Rows[1].Line)
+}
+
+func TestImportRunsInBatches(t *testing.T) {
+	service, userRepo := setupImportService()
+	input := "id\n"
+	for i := 0; i < 2*BatchSize+50; i++ {
+		input += fmt.Sprintf("user%d\n", i)
+	}
+
+	// A cancelled import stops before the next batch
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	report, err := service.ImportUsers(ctx, strings.NewReader(input), Options{Format: FormatCSV})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 0, report.Batches)
+	_, err = userRepo.FindByID(context.Background(), "user0")
+	assert.Error(t, err)
+
+	report, err = service.ImportUsers(context.Background(), strings.NewReader(input), Options{Format: FormatCSV})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, report.Batches)
+	assert.Equal(t, 2*BatchSize+50, report.Succeeded)
+	assert.Equal(t, StatusImported, report.Rows[2*BatchSize+49].Status)
+}
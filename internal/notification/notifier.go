@@ -0,0 +1,31 @@
+package notification
+
+import (
+	"context"
+	"log"
+)
+
+// Notification is a message to one user, e.g. about a change to one of their bookings.
+type Notification struct {
+	UserID  string
+	Subject string
+	Body    string
+}
+
+// Notifier delivers notifications. Implementations wrap a delivery channel such as e-mail;
+// the log notifier is used until one is configured.
+type Notifier interface {
+	Notify(ctx context.Context, n Notification) error
+}
+
+type logNotifier struct{}
+
+// NewLogNotifier returns a notifier that writes every notification to the standard logger.
+func NewLogNotifier() Notifier {
+	return &logNotifier{}
+}
+
+func (l *logNotifier) Notify(ctx context.Context, n Notification) error {
+	log.Printf("notify %s: %s: %s", n.UserID, n.Subject, n.Body)
+	return nil
+}
@@ -0,0 +1,62 @@
+package payment
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+var ErrDeclined = errors.New("payment declined")
+
+// Provider charges customers and refunds charges. Implementations wrap a payment service provider;
+// FakeProvider is used for local development and tests.
+type Provider interface {
+	Charge(ctx context.Context, req ChargeRequest) (*Charge, error)
+	Refund(ctx context.Context, req RefundRequest) (*Refund, error)
+}
+
+type ChargeRequest struct {
+	OrderID  string
+	Amount   int64 // minor units of Currency
+	Currency string
+	Token    string // payment method token from the front-end
+}
+
+type Charge struct {
+	ID     string
+	Amount int64
+}
+
+// RefundRequest returns part or all of an earlier charge.
+type RefundRequest struct {
+	ChargeID string
+	Amount   int64 // minor units of Currency
+	Currency string
+}
+
+type Refund struct {
+	ID     string
+	Amount int64
+}
+
+// DeclineToken makes FakeProvider decline the charge.
+const DeclineToken = "tok_decline"
+
+type fakeProvider struct{}
+
+// NewFakeProvider returns a provider that accepts every charge except those made with DeclineToken.
+func NewFakeProvider() Provider {
+	return &fakeProvider{}
+}
+
+func (p *fakeProvider) Charge(ctx context.Context, req ChargeRequest) (*Charge, error) {
+	if req.Token == DeclineToken {
+		return nil, ErrDeclined
+	}
+	return &Charge{ID: "fake_" + uuid.New().String(), Amount: req.Amount}, nil
+}
+
+func (p *fakeProvider) Refund(ctx context.Context, req RefundRequest) (*Refund, error) {
+	return &Refund{ID: "fake_refund_" + uuid.New().String(), Amount: req.Amount}, nil
+}
@@ -1,8 +1,13 @@
 package user
 
 import (
+	stderrors "errors"
 	"net/http"
 
+	"conference-booking/pkg/auth"
+	"conference-booking/pkg/errors"
+	"conference-booking/pkg/query"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -11,6 +16,8 @@ func RegisterRoutes(router *gin.Engine, repo Repository) {
 	group := router.Group("/user")
 	{
 		group.POST("", h.AddUser)
+		group.GET("", h.ListUsers)
+		group.GET("/:id/calendar-token", h.GetCalendarFeed)
 	}
 }
 
@@ -31,10 +38,44 @@ func (h *Handler) AddUser(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.AddUser(req); err != nil {
+	if err := h.service.AddUser(c.Request.Context(), req); err != nil {
 		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusCreated, gin.H{"created user": true})
 }
+
+func (h *Handler) ListUsers(c *gin.Context) {
+	q, err := query.FromRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	page, err := h.service.ListUsers(c.Request.Context(), q, auth.UserID(c))
+	if err != nil {
+		status := http.StatusBadRequest
+		if stderrors.Is(err, errors.ErrForbidden) {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *Handler) GetCalendarFeed(c *gin.Context) {
+	feed, err := h.service.GetCalendarFeed(c.Request.Context(), c.Param("id"), auth.UserID(c))
+	if err != nil {
+		status := http.StatusNotFound
+		if stderrors.Is(err, errors.ErrForbidden) {
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, feed)
+}
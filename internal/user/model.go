@@ -1,9 +1,22 @@
 package user
 
 type User struct {
-	ID string
+	ID            string `json:"id"`
+	Name          string `json:"name,omitempty"`
+	Email         string `json:"email,omitempty"`
+	CalendarToken string `json:"-"`
+	NoShows       int    `json:"no_shows,omitempty"` // confirmed bookings never checked in
+	Tier          string `json:"tier,omitempty"`     // waitlist tier of the user's bookings, see conference.TierPriority
+}
+
+type CalendarFeed struct {
+	Token string `json:"token"`
+	URL   string `json:"url"`
 }
 
 type AddUserRequest struct {
-	ID string `json:"id"`
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Tier  string `json:"-"` // set by bulk imports only; users cannot choose their own
 }
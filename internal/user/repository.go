@@ -1,14 +1,21 @@
 package user
 
 import (
+	"context"
 	"sync"
 
 	"conference-booking/pkg/errors"
+	"conference-booking/pkg/query"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Repository interface {
-	Create(user *User) error
-	FindByID(id string) (*User, error)
+	Create(ctx context.Context, user *User) error
+	FindByID(ctx context.Context, id string) (*User, error)
+	Update(ctx context.Context, user *User) error
+	List(ctx context.Context, q query.Query) (query.Page[*User], error)
 }
 
 type inMemoryRepository struct {
@@ -22,7 +29,10 @@ func NewInMemoryRepository() Repository {
 	}
 }
 
-func (r *inMemoryRepository) Create(user *User) error {
+func (r *inMemoryRepository) Create(ctx context.Context, user *User) error {
+	_, span := tracer.Start(ctx, "user.Repository.Create", trace.WithAttributes(attribute.String("user.id", user.ID)))
+	defer span.End()
+
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
@@ -34,7 +44,10 @@ func (r *inMemoryRepository) Create(user *User) error {
 	return nil
 }
 
-func (r *inMemoryRepository) FindByID(id string) (*User, error) {
+func (r *inMemoryRepository) FindByID(ctx context.Context, id string) (*User, error) {
+	_, span := tracer.Start(ctx, "user.Repository.FindByID", trace.WithAttributes(attribute.String("user.id", id)))
+	defer span.End()
+
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
@@ -45,3 +58,38 @@ func (r *inMemoryRepository) FindByID(id string) (*User, error) {
 
 	return user, nil
 }
+
+func (r *inMemoryRepository) Update(ctx context.Context, user *User) error {
+	_, span := tracer.Start(ctx, "user.Repository.Update", trace.WithAttributes(attribute.String("user.id", user.ID)))
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.users[user.ID]; !exists {
+		return errors.ErrNotFound
+	}
+
+	r.users[user.ID] = user
+	return nil
+}
+
+func (r *inMemoryRepository) List(ctx context.Context, q query.Query) (query.Page[*User], error) {
+	_, span := tracer.Start(ctx, "user.Repository.List")
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var users []*User
+	for _, user := range r.users {
+		if q.UserID != "" && user.ID != q.UserID {
+			continue
+		}
+		users = append(users, user)
+	}
+
+	return query.Apply(users, q, func(u *User) string { return u.ID }, map[string]query.Key[*User]{
+		"id": func(u *User) string { return u.ID },
+	})
+}
@@ -1,7 +1,24 @@
 package user
 
+import (
+	"context"
+	"net/url"
+
+	"conference-booking/pkg/errors"
+	"conference-booking/pkg/query"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("conference-booking/internal/user")
+
 type Service interface {
-	AddUser(req AddUserRequest) error
+	AddUser(ctx context.Context, req AddUserRequest) error
+	ListUsers(ctx context.Context, q query.Query, requesterID string) (query.Page[*User], error)
+	GetCalendarFeed(ctx context.Context, id, requesterID string) (*CalendarFeed, error)
 }
 
 type service struct {
@@ -12,7 +29,52 @@ func NewService(repo Repository) Service {
 	return &service{repo: repo}
 }
 
-func (s *service) AddUser(req AddUserRequest) error {
-	user := &User{ID: req.ID}
-	return s.repo.Create(user)
+func (s *service) AddUser(ctx context.Context, req AddUserRequest) error {
+	ctx, span := tracer.Start(ctx, "user.Service.AddUser", trace.WithAttributes(attribute.String("user.id", req.ID)))
+	defer span.End()
+
+	user := &User{ID: req.ID, Name: req.Name, Email: req.Email, Tier: req.Tier, CalendarToken: uuid.New().String()}
+	return s.repo.Create(ctx, user)
+}
+
+// ListUsers lists users to a known caller. The no-show count of a user is only shown to that user.
+func (s *service) ListUsers(ctx context.Context, q query.Query, requesterID string) (query.Page[*User], error) {
+	ctx, span := tracer.Start(ctx, "user.Service.ListUsers")
+	defer span.End()
+
+	if requesterID == "" {
+		return query.Page[*User]{}, errors.ErrForbidden
+	}
+	page, err := s.repo.List(ctx, q)
+	if err != nil {
+		return page, err
+	}
+	for i, user := range page.Items {
+		if user.ID != requesterID {
+			listed := *user
+			listed.NoShows = 0
+			page.Items[i] = &listed
+		}
+	}
+	return page, nil
+}
+
+// GetCalendarFeed returns the private calendar feed of a user. Only the user may read it.
+func (s *service) GetCalendarFeed(ctx context.Context, id, requesterID string) (*CalendarFeed, error) {
+	ctx, span := tracer.Start(ctx, "user.Service.GetCalendarFeed", trace.WithAttributes(attribute.String("user.id", id)))
+	defer span.End()
+
+	if id != requesterID {
+		return nil, errors.ErrForbidden
+	}
+
+	user, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CalendarFeed{
+		Token: user.CalendarToken,
+		URL:   "/user/" + url.PathEscape(user.ID) + "/calendar.ics?token=" + url.QueryEscape(user.CalendarToken),
+	}, nil
 }
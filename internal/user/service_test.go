@@ -1,8 +1,12 @@
 package user
 
 import (
+	"context"
 	"testing"
 
+	"conference-booking/pkg/errors"
+	"conference-booking/pkg/query"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -19,7 +23,7 @@ func TestAddUser(t *testing.T) {
 	service := setupUserService()
 
 	// Add a user
-	err := service.AddUser(AddUserRequest{
+	err := service.AddUser(context.Background(), AddUserRequest{
 		ID: "user1",
 	})
 
@@ -31,13 +35,13 @@ func TestAddDuplicateUser(t *testing.T) {
 	service := setupUserService()
 
 	// Add a user
-	err := service.AddUser(AddUserRequest{
+	err := service.AddUser(context.Background(), AddUserRequest{
 		ID: "user1",
 	})
 	assert.NoError(t, err)
 
 	// Attempt to add another user with the same ID
-	err = service.AddUser(AddUserRequest{
+	err = service.AddUser(context.Background(), AddUserRequest{
 		ID: "user1",
 	})
 
@@ -45,3 +49,69 @@ func TestAddDuplicateUser(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, "resource conflict", err.Error())
 }
+
+func TestListUsersPaginates(t *testing.T) {
+	service := setupUserService()
+	ctx := context.Background()
+
+	for _, id := range []string{"user3", "user1", "user2"} {
+		assert.NoError(t, service.AddUser(ctx, AddUserRequest{ID: id}))
+	}
+
+	// Only a known caller lists users
+	_, err := service.ListUsers(ctx, query.Query{}, "")
+	assert.ErrorIs(t, err, errors.ErrForbidden)
+
+	// First page holds the two lowest IDs
+	page, err := service.ListUsers(ctx, query.Query{Limit: 2}, "user1")
+	assert.NoError(t, err)
+	assert.Len(t, page.Items, 2)
+	assert.Equal(t, "user1", page.Items[0].ID)
+	assert.NotEmpty(t, page.NextCursor)
+
+	// Second page continues from the cursor and is the last one
+	page, err = service.ListUsers(ctx, query.Query{Limit: 2, Cursor: page.NextCursor}, "user1")
+	assert.NoError(t, err)
+	assert.Len(t, page.Items, 1)
+	assert.Equal(t, "user3", page.Items[0].ID)
+	assert.Empty(t, page.NextCursor)
+
+	// Descending sort
+	page, err = service.ListUsers(ctx, query.Query{Sort: "-id"}, "user1")
+	assert.NoError(t, err)
+	assert.Equal(t, "user3", page.Items[0].ID)
+}
+
+func TestListUsersShowsNoShowsToTheUserOnly(t *testing.T) {
+	repo := setupUserRepository()
+	service := NewService(repo)
+	ctx := context.Background()
+
+	assert.NoError(t, repo.Create(ctx, &User{ID: "user1", NoShows: 2}))
+	assert.NoError(t, repo.Create(ctx, &User{ID: "user2", NoShows: 1}))
+
+	page, err := service.ListUsers(ctx, query.Query{}, "user1")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, page.Items[0].NoShows)
+	assert.Zero(t, page.Items[1].NoShows)
+
+	// The stored count is untouched
+	stored, err := repo.FindByID(ctx, "user2")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, stored.NoShows)
+}
+
+func TestGetCalendarFeedOnlyForOwnUser(t *testing.T) {
+	service := setupUserService()
+	ctx := context.Background()
+	assert.NoError(t, service.AddUser(ctx, AddUserRequest{ID: "user1"}))
+
+	// Another user cannot read the token
+	_, err := service.GetCalendarFeed(ctx, "user1", "user2")
+	assert.ErrorIs(t, err, errors.ErrForbidden)
+
+	feed, err := service.GetCalendarFeed(ctx, "user1", "user1")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, feed.Token)
+	assert.Contains(t, feed.URL, "/user/user1/calendar.ics?token=")
+}
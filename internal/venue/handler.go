@@ -0,0 +1,122 @@
+package venue
+
+import (
+	stderrors "errors"
+	"net/http"
+
+	"conference-booking/pkg/auth"
+	"conference-booking/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+func RegisterRoutes(router *gin.Engine, repo Repository) {
+	h := NewHandler(repo)
+	group := router.Group("/venue")
+	{
+		group.POST("", h.AddVenue)
+		group.GET("", h.ListVenues)
+		group.GET("/:id", h.GetVenue)
+		group.POST("/:id/rooms", h.AddRoom)
+		group.POST("/:id/organisers", h.GrantOrganiser)
+		group.DELETE("/:id/organisers/:user_id", h.RevokeOrganiser)
+	}
+}
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(repo Repository) *Handler {
+	return &Handler{
+		service: NewService(repo),
+	}
+}
+
+func (h *Handler) AddVenue(c *gin.Context) {
+	var req AddVenueRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The creator becomes the owner of the venue
+	req.OwnerID = auth.UserID(c)
+
+	venue, err := h.service.AddVenue(c.Request.Context(), req)
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, venue)
+}
+
+func (h *Handler) ListVenues(c *gin.Context) {
+	c.JSON(http.StatusOK, h.service.ListVenues(c.Request.Context()))
+}
+
+func (h *Handler) GetVenue(c *gin.Context) {
+	venue, err := h.service.GetVenue(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, venue)
+}
+
+func (h *Handler) AddRoom(c *gin.Context) {
+	var req AddRoomRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	room, err := h.service.AddRoom(c.Request.Context(), c.Param("id"), req, auth.UserID(c))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, room)
+}
+
+func (h *Handler) GrantOrganiser(c *gin.Context) {
+	var req GrantOrganiserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	venue, err := h.service.GrantOrganiser(c.Request.Context(), c.Param("id"), req, auth.UserID(c))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, venue)
+}
+
+func (h *Handler) RevokeOrganiser(c *gin.Context) {
+	venue, err := h.service.RevokeOrganiser(c.Request.Context(), c.Param("id"), c.Param("user_id"), auth.UserID(c))
+	if err != nil {
+		c.JSON(errorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, venue)
+}
+
+func errorStatus(err error) int {
+	switch {
+	case stderrors.Is(err, errors.ErrInvalidInput):
+		return http.StatusBadRequest
+	case stderrors.Is(err, errors.ErrForbidden):
+		return http.StatusForbidden
+	case stderrors.Is(err, errors.ErrNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusConflict
+	}
+}
@@ -0,0 +1,42 @@
+package venue
+
+import "slices"
+
+// Venue is a physical location with one or more rooms. Organisers are the users the owner has
+// granted its rooms to.
+type Venue struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name"`
+	Address    string   `json:"address,omitempty"`
+	OwnerID    string   `json:"owner_id,omitempty"`
+	Organisers []string `json:"organisers,omitempty"`
+	Rooms      []*Room  `json:"rooms"`
+}
+
+// Hosts reports whether the user may hold conferences in the rooms of the venue.
+func (v *Venue) Hosts(userID string) bool {
+	return userID != "" && (v.OwnerID == userID || slices.Contains(v.Organisers, userID))
+}
+
+// Room is a space of a venue. Capacity is the most people it physically holds.
+type Room struct {
+	ID       string `json:"id"`
+	VenueID  string `json:"venue_id"`
+	Name     string `json:"name"`
+	Capacity int    `json:"capacity"`
+}
+
+type AddVenueRequest struct {
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	OwnerID string `json:"-"`
+}
+
+type AddRoomRequest struct {
+	Name     string `json:"name"`
+	Capacity int    `json:"capacity"`
+}
+
+type GrantOrganiserRequest struct {
+	UserID string `json:"user_id"`
+}
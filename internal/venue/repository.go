@@ -0,0 +1,151 @@
+package venue
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+
+	"conference-booking/pkg/errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type Repository interface {
+	CreateVenue(ctx context.Context, venue *Venue) error
+	FindVenue(ctx context.Context, id string) (*Venue, error)
+	ListVenues(ctx context.Context) []*Venue
+	CreateRoom(ctx context.Context, room *Room) error
+	FindRoom(ctx context.Context, id string) (*Room, error)
+	AddOrganiser(ctx context.Context, venueID, userID string) (*Venue, error)
+	RemoveOrganiser(ctx context.Context, venueID, userID string) (*Venue, error)
+}
+
+type inMemoryRepository struct {
+	venues map[string]*Venue
+	rooms  map[string]*Room
+	mutex  sync.Mutex
+}
+
+func NewInMemoryRepository() Repository {
+	return &inMemoryRepository{
+		venues: make(map[string]*Venue),
+		rooms:  make(map[string]*Room),
+	}
+}
+
+func (r *inMemoryRepository) CreateVenue(ctx context.Context, venue *Venue) error {
+	_, span := tracer.Start(ctx, "venue.Repository.CreateVenue", trace.WithAttributes(attribute.String("venue.id", venue.ID)))
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.venues[venue.ID]; exists {
+		return errors.ErrConflict
+	}
+	r.venues[venue.ID] = venue
+	return nil
+}
+
+func (r *inMemoryRepository) FindVenue(ctx context.Context, id string) (*Venue, error) {
+	_, span := tracer.Start(ctx, "venue.Repository.FindVenue", trace.WithAttributes(attribute.String("venue.id", id)))
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	venue, exists := r.venues[id]
+	if !exists {
+		return nil, errors.ErrNotFound
+	}
+	return venue, nil
+}
+
+// ListVenues returns all venues ordered by name.
+func (r *inMemoryRepository) ListVenues(ctx context.Context) []*Venue {
+	_, span := tracer.Start(ctx, "venue.Repository.ListVenues")
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	venues := make([]*Venue, 0, len(r.venues))
+	for _, venue := range r.venues {
+		venues = append(venues, venue)
+	}
+	sort.Slice(venues, func(i, j int) bool {
+		if venues[i].Name != venues[j].Name {
+			return venues[i].Name < venues[j].Name
+		}
+		return venues[i].ID < venues[j].ID
+	})
+	return venues
+}
+
+// CreateRoom adds a room to its venue.
+func (r *inMemoryRepository) CreateRoom(ctx context.Context, room *Room) error {
+	_, span := tracer.Start(ctx, "venue.Repository.CreateRoom", trace.WithAttributes(attribute.String("venue.id", room.VenueID), attribute.String("room.id", room.ID)))
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	venue, exists := r.venues[room.VenueID]
+	if !exists {
+		return errors.ErrNotFound
+	}
+	r.rooms[room.ID] = room
+	venue.Rooms = append(venue.Rooms, room)
+	return nil
+}
+
+func (r *inMemoryRepository) FindRoom(ctx context.Context, id string) (*Room, error) {
+	_, span := tracer.Start(ctx, "venue.Repository.FindRoom", trace.WithAttributes(attribute.String("room.id", id)))
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	room, exists := r.rooms[id]
+	if !exists {
+		return nil, errors.ErrNotFound
+	}
+	return room, nil
+}
+
+// AddOrganiser grants the rooms of a venue to a user. Granting twice is a no-op.
+func (r *inMemoryRepository) AddOrganiser(ctx context.Context, venueID, userID string) (*Venue, error) {
+	_, span := tracer.Start(ctx, "venue.Repository.AddOrganiser", trace.WithAttributes(attribute.String("venue.id", venueID), attribute.String("user.id", userID)))
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	venue, exists := r.venues[venueID]
+	if !exists {
+		return nil, errors.ErrNotFound
+	}
+	if !slices.Contains(venue.Organisers, userID) {
+		// Readers may hold the old slice, so it is replaced rather than appended to
+		venue.Organisers = append(slices.Clone(venue.Organisers), userID)
+	}
+	return venue, nil
+}
+
+// RemoveOrganiser takes back a grant. Conferences already in the rooms keep them.
+func (r *inMemoryRepository) RemoveOrganiser(ctx context.Context, venueID, userID string) (*Venue, error) {
+	_, span := tracer.Start(ctx, "venue.Repository.RemoveOrganiser", trace.WithAttributes(attribute.String("venue.id", venueID), attribute.String("user.id", userID)))
+	defer span.End()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	venue, exists := r.venues[venueID]
+	if !exists {
+		return nil, errors.ErrNotFound
+	}
+	venue.Organisers = slices.DeleteFunc(slices.Clone(venue.Organisers), func(id string) bool { return id == userID })
+	return venue, nil
+}
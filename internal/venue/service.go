@@ -0,0 +1,133 @@
+package venue
+
+import (
+	"context"
+
+	"conference-booking/pkg/errors"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("conference-booking/internal/venue")
+
+type Service interface {
+	AddVenue(ctx context.Context, req AddVenueRequest) (*Venue, error)
+	ListVenues(ctx context.Context) []*Venue
+	GetVenue(ctx context.Context, id string) (*Venue, error)
+	AddRoom(ctx context.Context, venueID string, req AddRoomRequest, requesterID string) (*Room, error)
+	GrantOrganiser(ctx context.Context, venueID string, req GrantOrganiserRequest, requesterID string) (*Venue, error)
+	RevokeOrganiser(ctx context.Context, venueID, userID, requesterID string) (*Venue, error)
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{repo: repo}
+}
+
+// AddVenue creates a venue. The creator becomes its owner.
+func (s *service) AddVenue(ctx context.Context, req AddVenueRequest) (*Venue, error) {
+	ctx, span := tracer.Start(ctx, "venue.Service.AddVenue")
+	defer span.End()
+
+	if req.Name == "" {
+		return nil, errors.ErrInvalidInput
+	}
+
+	venue := &Venue{
+		ID:      uuid.New().String(),
+		Name:    req.Name,
+		Address: req.Address,
+		OwnerID: req.OwnerID,
+		Rooms:   []*Room{},
+	}
+	if err := s.repo.CreateVenue(ctx, venue); err != nil {
+		return nil, err
+	}
+	return venue, nil
+}
+
+func (s *service) ListVenues(ctx context.Context) []*Venue {
+	ctx, span := tracer.Start(ctx, "venue.Service.ListVenues")
+	defer span.End()
+
+	return s.repo.ListVenues(ctx)
+}
+
+func (s *service) GetVenue(ctx context.Context, id string) (*Venue, error) {
+	ctx, span := tracer.Start(ctx, "venue.Service.GetVenue", trace.WithAttributes(attribute.String("venue.id", id)))
+	defer span.End()
+
+	return s.repo.FindVenue(ctx, id)
+}
+
+// AddRoom adds a room to a venue. Only the owner of the venue may add rooms.
+func (s *service) AddRoom(ctx context.Context, venueID string, req AddRoomRequest, requesterID string) (*Room, error) {
+	ctx, span := tracer.Start(ctx, "venue.Service.AddRoom", trace.WithAttributes(attribute.String("venue.id", venueID)))
+	defer span.End()
+
+	venue, err := s.repo.FindVenue(ctx, venueID)
+	if err != nil {
+		return nil, err
+	}
+	if venue.OwnerID == "" || venue.OwnerID != requesterID {
+		return nil, errors.ErrForbidden
+	}
+	if req.Name == "" || req.Capacity <= 0 {
+		return nil, errors.ErrInvalidInput
+	}
+
+	room := &Room{
+		ID:       uuid.New().String(),
+		VenueID:  venue.ID,
+		Name:     req.Name,
+		Capacity: req.Capacity,
+	}
+	if err := s.repo.CreateRoom(ctx, room); err != nil {
+		return nil, err
+	}
+	return room, nil
+}
+
+// GrantOrganiser lets a user hold conferences in the rooms of a venue. Only the owner of the venue
+// may grant its rooms.
+func (s *service) GrantOrganiser(ctx context.Context, venueID string, req GrantOrganiserRequest, requesterID string) (*Venue, error) {
+	ctx, span := tracer.Start(ctx, "venue.Service.GrantOrganiser", trace.WithAttributes(attribute.String("venue.id", venueID), attribute.String("user.id", req.UserID)))
+	defer span.End()
+
+	if err := s.checkOwner(ctx, venueID, requesterID); err != nil {
+		return nil, err
+	}
+	if req.UserID == "" {
+		return nil, errors.ErrInvalidInput
+	}
+	return s.repo.AddOrganiser(ctx, venueID, req.UserID)
+}
+
+// RevokeOrganiser takes back a grant. It stops new conferences from using the rooms; conferences
+// already placed there keep their room.
+func (s *service) RevokeOrganiser(ctx context.Context, venueID, userID, requesterID string) (*Venue, error) {
+	ctx, span := tracer.Start(ctx, "venue.Service.RevokeOrganiser", trace.WithAttributes(attribute.String("venue.id", venueID), attribute.String("user.id", userID)))
+	defer span.End()
+
+	if err := s.checkOwner(ctx, venueID, requesterID); err != nil {
+		return nil, err
+	}
+	return s.repo.RemoveOrganiser(ctx, venueID, userID)
+}
+
+func (s *service) checkOwner(ctx context.Context, venueID, requesterID string) error {
+	venue, err := s.repo.FindVenue(ctx, venueID)
+	if err != nil {
+		return err
+	}
+	if venue.OwnerID == "" || venue.OwnerID != requesterID {
+		return errors.ErrForbidden
+	}
+	return nil
+}
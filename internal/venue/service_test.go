@@ -0,0 +1,60 @@
+package venue
+
+import (
+	"context"
+	"testing"
+
+	"conference-booking/pkg/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddVenueAndRooms(t *testing.T) {
+	service := NewService(NewInMemoryRepository())
+	ctx := context.Background()
+
+	venue, err := service.AddVenue(ctx, AddVenueRequest{Name: "Expo Center", OwnerID: "owner"})
+	assert.NoError(t, err)
+
+	// Only the owner adds rooms, and rooms need a capacity
+	_, err = service.AddRoom(ctx, venue.ID, AddRoomRequest{Name: "Hall A", Capacity: 100}, "someone")
+	assert.ErrorIs(t, err, errors.ErrForbidden)
+	_, err = service.AddRoom(ctx, venue.ID, AddRoomRequest{Name: "Hall A"}, "owner")
+	assert.ErrorIs(t, err, errors.ErrInvalidInput)
+	room, err := service.AddRoom(ctx, venue.ID, AddRoomRequest{Name: "Hall A", Capacity: 100}, "owner")
+	assert.NoError(t, err)
+
+	found, err := service.GetVenue(ctx, venue.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []*Room{room}, found.Rooms)
+	assert.Len(t, service.ListVenues(ctx), 1)
+}
+
+func TestOrganisersAreGrantedByTheOwner(t *testing.T) {
+	service := NewService(NewInMemoryRepository())
+	ctx := context.Background()
+
+	venue, err := service.AddVenue(ctx, AddVenueRequest{Name: "Expo Center", OwnerID: "owner"})
+	assert.NoError(t, err)
+	assert.True(t, venue.Hosts("owner"))
+	assert.False(t, venue.Hosts("organiser"))
+
+	_, err = service.GrantOrganiser(ctx, venue.ID, GrantOrganiserRequest{UserID: "organiser"}, "organiser")
+	assert.ErrorIs(t, err, errors.ErrForbidden)
+	_, err = service.GrantOrganiser(ctx, venue.ID, GrantOrganiserRequest{}, "owner")
+	assert.ErrorIs(t, err, errors.ErrInvalidInput)
+
+	// Granting twice is a no-op
+	_, err = service.GrantOrganiser(ctx, venue.ID, GrantOrganiserRequest{UserID: "organiser"}, "owner")
+	assert.NoError(t, err)
+	venue, err = service.GrantOrganiser(ctx, venue.ID, GrantOrganiserRequest{UserID: "organiser"}, "owner")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"organiser"}, venue.Organisers)
+	assert.True(t, venue.Hosts("organiser"))
+
+	_, err = service.RevokeOrganiser(ctx, venue.ID, "organiser", "organiser")
+	assert.ErrorIs(t, err, errors.ErrForbidden)
+	venue, err = service.RevokeOrganiser(ctx, venue.ID, "organiser", "owner")
+	assert.NoError(t, err)
+	assert.False(t, venue.Hosts("organiser"))
+}
@@ -0,0 +1,12 @@
+package auth
+
+import "github.com/gin-gonic/gin"
+
+// Header carries the ID of the calling user. There is no authentication yet,
+// so the value is trusted as given.
+const Header = "X-User-ID"
+
+// UserID returns the ID of the calling user, or an empty string when absent.
+func UserID(c *gin.Context) string {
+	return c.GetHeader(Header)
+}
@@ -10,4 +10,5 @@ var (
 	ErrWaitlistExpired = errors.New("waitlist confirmation expired")
 	ErrBookingConflict = errors.New("user already has a confirmed booking")
 	ErrInvalidAction   = errors.New("action not allowed")
+	ErrForbidden       = errors.New("forbidden")
 )
@@ -0,0 +1,48 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteEscapesTextAndUsesUTC(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	assert.NoError(t, err)
+	start := time.Date(2026, time.June, 1, 9, 30, 0, 0, berlin)
+
+	var b strings.Builder
+	assert.NoError(t, Write(&b, "Talks; Workshops", []Event{{
+		UID:         "booking-1@conference-booking",
+		Summary:     `Go, Rust; C\C++`,
+		Description: "Line one\nLine two",
+		Start:       start,
+		End:         start.Add(time.Hour),
+	}}))
+	out := b.String()
+
+	// Every line ends in CRLF and text values escape their special characters
+	assert.True(t, strings.HasSuffix(out, "END:VCALENDAR\r\n"))
+	assert.NotContains(t, strings.ReplaceAll(out, "\r\n", ""), "\n")
+	assert.Contains(t, out, `X-WR-CALNAME:Talks\; Workshops`+"\r\n")
+	assert.Contains(t, out, `SUMMARY:Go\, Rust\; C\\C++`+"\r\n")
+	assert.Contains(t, out, `DESCRIPTION:Line one\nLine two`+"\r\n")
+
+	// Times are written in UTC whatever their zone
+	assert.Contains(t, out, "DTSTART:20260601T073000Z\r\n")
+	assert.Contains(t, out, "DTEND:20260601T083000Z\r\n")
+}
+
+func TestFoldKeepsLinesShortAndRunesWhole(t *testing.T) {
+	line := "SUMMARY:" + strings.Repeat("Konferenz über Käse ", 10)
+	folded := fold(line)
+
+	for _, part := range strings.Split(folded, "\r\n") {
+		assert.LessOrEqual(t, len(part), 75)
+	}
+	// Continuation lines start with a space, and unfolding gives the line back
+	assert.Equal(t, line, strings.ReplaceAll(folded, "\r\n ", ""))
+	assert.Equal(t, "DTSTART:20260601T073000Z", fold("DTSTART:20260601T073000Z"))
+}
@@ -0,0 +1,91 @@
+package openapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"conference-booking/internal/booking"
+	"conference-booking/internal/conference"
+	"conference-booking/internal/importer"
+	"conference-booking/internal/notification"
+	"conference-booking/internal/payment"
+	"conference-booking/internal/user"
+	"conference-booking/internal/venue"
+	"conference-booking/pkg/checkin"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+var ginParam = regexp.MustCompile(`:([^/]+)`)
+
+func setupRouter(t *testing.T) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	doc, err := Load()
+	assert.NoError(t, err)
+	validate, err := ValidateRequests(doc)
+	assert.NoError(t, err)
+	router.Use(validate)
+
+	conferenceStore := conference.NewInMemoryRepository()
+	userStore := user.NewInMemoryRepository()
+	bookingStore := booking.NewInMemoryRepository(conferenceStore)
+	venueStore := venue.NewInMemoryRepository()
+	payments := payment.NewFakeProvider()
+	notifier := notification.NewLogNotifier()
+	signer, err := checkin.NewSigner("test-secret")
+	assert.NoError(t, err)
+
+	RegisterRoutes(router)
+	conference.RegisterRoutes(router, conferenceStore, venueStore, conference.DefaultConfig)
+	venue.RegisterRoutes(router, venueStore)
+	user.RegisterRoutes(router, userStore)
+	booking.RegisterRoutes(router, conferenceStore, userStore, bookingStore, payments, notifier, signer, booking.Config{})
+	importer.RegisterRoutes(router, conferenceStore, userStore, bookingStore, payments, notifier, signer, booking.Config{})
+	return router
+}
+
+func TestSpecMatchesRoutes(t *testing.T) {
+	router := setupRouter(t)
+	doc, err := Load()
+	assert.NoError(t, err)
+
+	var registered []string
+	for _, route := range router.Routes() {
+		path := ginParam.ReplaceAllString(route.Path, "{$1}")
+		registered = append(registered, route.Method+" "+path)
+	}
+
+	var documented []string
+	for path, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			documented = append(documented, strings.ToUpper(method)+" "+path)
+		}
+	}
+
+	sort.Strings(registered)
+	sort.Strings(documented)
+	assert.Equal(t, registered, documented)
+}
+
+func TestValidateRequestsRejectsInvalidBody(t *testing.T) {
+	router := setupRouter(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/user", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	req = httptest.NewRequest(http.MethodPost, "/user", strings.NewReader(`{"id": "user1"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+}
@@ -0,0 +1,166 @@
+package query
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"conference-booking/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// Query is the shared list model understood by every repository.
+// Filters that do not apply to a resource are ignored by its repository.
+type Query struct {
+	Cursor       string
+	Limit        int
+	Sort         string // field name, prefixed with "-" for descending order
+	Status       string
+	ConferenceID string
+	UserID       string
+	From         *time.Time
+	To           *time.Time
+}
+
+// Page is one slice of a listing. NextCursor is empty on the last page.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// Key renders the field an item is sorted by as a string that orders like the field itself.
+// Use TimeKey and IntKey for non-string fields.
+type Key[T any] func(item T) string
+
+// TimeKey renders a time so that keys order chronologically.
+func TimeKey(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000000000Z")
+}
+
+// IntKey renders an integer so that keys order numerically, negative numbers included.
+func IntKey(n int) string {
+	return fmt.Sprintf("%020d", uint64(n)^(1<<63))
+}
+
+// cursor is the position after which the next page starts: the sort key and ID of the last item
+// returned. It does not refer to the item itself, so it stays valid when that item changes or goes.
+type cursor struct {
+	Key string `json:"k"`
+	ID  string `json:"id"`
+}
+
+// FromRequest reads a Query from the URL parameters of a list request.
+func FromRequest(c *gin.Context) (Query, error) {
+	q := Query{
+		Cursor:       c.Query("cursor"),
+		Limit:        DefaultLimit,
+		Sort:         c.Query("sort"),
+		Status:       c.Query("status"),
+		ConferenceID: c.Query("conference"),
+		UserID:       c.Query("user"),
+	}
+
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			return Query{}, errors.ErrInvalidInput
+		}
+		q.Limit = limit
+	}
+
+	for name, target := range map[string]**time.Time{"from": &q.From, "to": &q.To} {
+		if raw := c.Query(name); raw != "" {
+			t, err := time.Parse(time.RFC3339, raw)
+			if err != nil {
+				return Query{}, errors.ErrInvalidInput
+			}
+			*target = &t
+		}
+	}
+
+	return q, nil
+}
+
+// InRange reports whether t falls within the [From, To) window of the query.
+func (q Query) InRange(t time.Time) bool {
+	if q.From != nil && t.Before(*q.From) {
+		return false
+	}
+	if q.To != nil && !t.Before(*q.To) {
+		return false
+	}
+	return true
+}
+
+// Apply sorts the already-filtered items, then returns the page following the cursor.
+// Items are ordered by the requested field with the ID as tie-breaker. The cursor is a keyset
+// (the sort key and ID of the last returned item), so pages neither skip nor repeat items when
+// the listing changes between requests.
+func Apply[T any](items []T, q Query, id func(T) string, keys map[string]Key[T]) (Page[T], error) {
+	field, desc := strings.TrimPrefix(q.Sort, "-"), strings.HasPrefix(q.Sort, "-")
+
+	key := func(T) string { return "" }
+	if field != "" {
+		var ok bool
+		if key, ok = keys[field]; !ok {
+			return Page[T]{}, errors.ErrInvalidInput
+		}
+	}
+	compare := func(a, b cursor) int {
+		c := strings.Compare(a.Key, b.Key)
+		if c == 0 {
+			c = strings.Compare(a.ID, b.ID)
+		}
+		if desc {
+			return -c
+		}
+		return c
+	}
+	position := func(item T) cursor { return cursor{Key: key(item), ID: id(item)} }
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return compare(position(items[i]), position(items[j])) < 0
+	})
+
+	start := 0
+	if q.Cursor != "" {
+		raw, err := base64.RawURLEncoding.DecodeString(q.Cursor)
+		if err != nil {
+			return Page[T]{}, errors.ErrInvalidInput
+		}
+		var last cursor
+		if err := json.Unmarshal(raw, &last); err != nil {
+			return Page[T]{}, errors.ErrInvalidInput
+		}
+		start = sort.Search(len(items), func(i int) bool { return compare(position(items[i]), last) > 0 })
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+
+	end := min(start+limit, len(items))
+	page := Page[T]{Items: items[start:end]}
+	if page.Items == nil {
+		page.Items = []T{}
+	}
+	if end < len(items) {
+		raw, _ := json.Marshal(position(items[end-1]))
+		page.NextCursor = base64.RawURLEncoding.EncodeToString(raw)
+	}
+	return page, nil
+}